@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+//go:embed accessible.tmpl
+var accessibleTemplate string
+
+// a11yPalette are the colors of the accessible layout, as CSS hex colors.
+type a11yPalette struct {
+	Page   string
+	Card   string
+	Text   string
+	Price  string
+	Border string
+	Focus  string
+}
+
+var accessiblePalette = a11yPalette{
+	Page:   "#f5f7fa",
+	Card:   "#ffffff",
+	Text:   "#222c36",
+	Price:  "#8a4500", // the page's orange accent, darkened to reach 4.5:1
+	Border: "#8a949e",
+	Focus:  "#8a4500",
+}
+
+// minContrast is the WCAG 2.1 AA contrast ratio for normal text, and
+// minUIContrast the one for borders and focus indicators.
+const (
+	minContrast   = 4.5
+	minUIContrast = 3.0
+)
+
+// renderAccessible renders the week with semantic headings, tables and ARIA
+// tabs. The output is checked before it is returned, so a template or
+// palette change that breaks accessibility fails the run instead of being
+// published.
+func renderAccessible(days []DayMenus, title string) ([]byte, error) {
+	if err := checkPaletteContrast(accessiblePalette); err != nil {
+		return nil, err
+	}
+	data := struct {
+		Title   string
+		Palette a11yPalette
+		Days    []DayMenus
+	}{Title: title, Palette: accessiblePalette, Days: days}
+
+	limits := new(templateLimits)
+	tmpl, err := template.New("accessible").Funcs(limits.funcs()).Parse(accessibleTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing accessible template: %w", err)
+	}
+	if err := parseCustomTemplate(tmpl, "accessible.tmpl", limits); err != nil {
+		return nil, err
+	}
+	output, err := executeTemplate(tmpl, data, limits)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering accessible page: %w", err)
+	}
+	if err := checkAccessibleMarkup(output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// checkPaletteContrast checks the color pairs of the accessible layout
+// against the WCAG AA contrast ratios.
+func checkPaletteContrast(p a11yPalette) error {
+	pairs := []struct {
+		What        string
+		Fg, Bg      string
+		MinContrast float64
+	}{
+		{"text on page", p.Text, p.Page, minContrast},
+		{"text on card", p.Text, p.Card, minContrast},
+		{"selected tab", p.Card, p.Text, minContrast},
+		{"price on card", p.Price, p.Card, minContrast},
+		{"table border on card", p.Border, p.Card, minUIContrast},
+		{"focus outline on page", p.Focus, p.Page, minUIContrast},
+	}
+	var problems []string
+	for _, pair := range pairs {
+		fg, err := parseHexColor(pair.Fg)
+		if err != nil {
+			return err
+		}
+		bg, err := parseHexColor(pair.Bg)
+		if err != nil {
+			return err
+		}
+		if ratio := contrastRatio(fg, bg); ratio < pair.MinContrast {
+			problems = append(problems, fmt.Sprintf("%s %s on %s has contrast %.2f:1, want %.1f:1", pair.What, pair.Fg, pair.Bg, ratio, pair.MinContrast))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("insufficient contrast: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// contrastRatio returns the WCAG contrast ratio of two colors, from 1 to 21.
+func contrastRatio(a, b color.RGBA) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	return (max(la, lb) + 0.05) / (min(la, lb) + 0.05)
+}
+
+// relativeLuminance implements the WCAG definition of relative luminance.
+func relativeLuminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// checkAccessibleMarkup checks rendered HTML for the structure the
+// accessible layout promises: a document language, a single h1, no skipped
+// heading levels, scoped table headers and tabs wired to their panels.
+func checkAccessibleMarkup(page []byte) error {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return fmt.Errorf("error parsing accessible page: %w", err)
+	}
+	var problems []string
+	if lang, _ := doc.Find("html").Attr("lang"); lang == "" {
+		problems = append(problems, "missing document language")
+	}
+	if n := doc.Find("h1").Length(); n != 1 {
+		problems = append(problems, fmt.Sprintf("%d h1 headings, want 1", n))
+	}
+	level := 0
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, h *goquery.Selection) {
+		l := int(goquery.NodeName(h)[1] - '0')
+		if l > level+1 {
+			problems = append(problems, fmt.Sprintf("heading %q skips from h%d to h%d", strings.TrimSpace(h.Text()), level, l))
+		}
+		level = l
+	})
+	doc.Find("th").Each(func(_ int, th *goquery.Selection) {
+		if scope, _ := th.Attr("scope"); scope != "col" && scope != "row" {
+			problems = append(problems, fmt.Sprintf("table header %q without scope", strings.TrimSpace(th.Text())))
+		}
+	})
+	doc.Find("table").Each(func(_ int, table *goquery.Selection) {
+		if strings.TrimSpace(table.Find("caption").Text()) == "" {
+			problems = append(problems, "table without caption")
+		}
+	})
+	doc.Find(`[role="tab"]`).Each(func(_ int, tab *goquery.Selection) {
+		id, _ := tab.Attr("id")
+		controls, _ := tab.Attr("aria-controls")
+		panel := doc.Find(`[role="tabpanel"]#` + controls)
+		if controls == "" || panel.Length() != 1 {
+			problems = append(problems, fmt.Sprintf("tab %q doesn't control a panel", strings.TrimSpace(tab.Text())))
+		} else if labelledBy, _ := panel.Attr("aria-labelledby"); labelledBy != id {
+			problems = append(problems, fmt.Sprintf("panel %q isn't labelled by its tab", controls))
+		}
+	})
+	if len(problems) > 0 {
+		return fmt.Errorf("accessibility check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
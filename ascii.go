@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// asciiOutput restricts the text and md formats to ASCII, for legacy
+// terminals and SMS gateways; set with -ascii.
+var asciiOutput bool
+
+// asciiReplacer transliterates the characters that have a conventional
+// ASCII spelling. Each box-drawing character maps to a single one, so
+// tables stay aligned.
+var asciiReplacer = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ß", "ss",
+	"–", "-", "—", "-", "‚", "'", "‘", "'", "’", "'", "„", `"`, "“", `"`, "”", `"`,
+	"«", `"`, "»", `"`, "…", "...", "€", "EUR", "≈", "~", "×", "x", "·", "-",
+	"─", "-", "│", "|", "┌", "+", "┬", "+", "┐", "+", "├", "+", "┼", "+", "┤", "+", "└", "+", "┴", "+", "┘", "+",
+)
+
+// toASCII transliterates s to ASCII. Accents are dropped, e.g. "é" becomes
+// "e"; anything else without an ASCII spelling, like emoji, is removed.
+func toASCII(s string) string {
+	s = asciiReplacer.Replace(norm.NFC.String(s))
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r < unicode.MaxASCII:
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// asciiDays returns a copy of days with all text transliterated by toASCII.
+func asciiDays(days []DayMenus) []DayMenus {
+	converted := make([]DayMenus, len(days))
+	for i, day := range days {
+		day.Name = toASCII(day.Name)
+		menus := make([]MenuView, len(day.Menus))
+		for j, menu := range day.Menus {
+			menu.Source.Name = toASCII(menu.Source.Name)
+			menu.Source.Short = toASCII(menu.Source.Short)
+			menu.Closed = toASCII(menu.Closed)
+			annotations := make([]Annotation, len(menu.Annotations))
+			for k, annotation := range menu.Annotations {
+				annotation.Message = toASCII(annotation.Message)
+				annotations[k] = annotation
+			}
+			menu.Annotations = annotations
+			categories := make([]CategoryView, len(menu.Categories))
+			for k, category := range menu.Categories {
+				category.Name = toASCII(category.Name)
+				dishes := make([]DishView, len(category.Dishes))
+				for l, dish := range category.Dishes {
+					dish.Title = toASCII(dish.Title)
+					dish.Price = toASCII(dish.Price)
+					dishes[l] = dish
+				}
+				category.Dishes = dishes
+				categories[k] = category
+			}
+			menu.Categories = categories
+			menus[j] = menu
+		}
+		day.Menus = menus
+		converted[i] = day
+	}
+	return converted
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// siteURL is the public address of the menu page, used for links and ids in
+// feeds.
+var siteURL = "https://menu.krenn.dev/"
+
+const feedTitle = "JKU Mensa & KHG Menu"
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Content atomContent `xml:"content"`
+}
+
+// feedEntryState remembers when an entry's content last changed, so
+// re-fetching an unchanged menu doesn't bump its updated timestamp.
+type feedEntryState struct {
+	Hash    string    `json:"hash"`
+	Updated time.Time `json:"updated"`
+}
+
+// feedItem is an entry of the Atom and JSON feeds: one canteen on one
+// weekday.
+type feedItem struct {
+	ID      string
+	Title   string
+	HTML    string
+	Updated time.Time
+}
+
+// feedTagDate is the date of the feed's tag URI. It is fixed, so the feed
+// id stays the same from year to year.
+const feedTagDate = "2025"
+
+// buildFeedItems prepares the entries shared by the feed renderers. Ids are
+// derived from ISO week, weekday and source, so they stay the same across
+// runs and aggregators can dedupe them. updated is the newest entry's time.
+func buildFeedItems(days []DayMenus, weekMenu WeekMenu) (feedID string, items []feedItem, updated time.Time, err error) {
+	year, week := weekMenu.Year, weekMenu.Week
+	host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(siteURL, "https://"), "http://"), "/")
+	tagPrefix := fmt.Sprintf("tag:%s,%s:", host, feedTagDate)
+
+	state, err := loadFeedState()
+	if err != nil {
+		return "", nil, time.Time{}, err
+	}
+	now := time.Now().UTC()
+	for _, day := range days {
+		for _, menu := range day.Canteens() {
+			if len(menu.Categories) == 0 {
+				continue
+			}
+			source := menu.Source
+			id := fmt.Sprintf("tag:%s,%d:%d-W%02d-%d-%s", host, year, year, week, weekdayOf(day.Date), source.ID)
+			content := menuViewHTML(menu)
+
+			sum := sha256.Sum256([]byte(content))
+			hash := hex.EncodeToString(sum[:])
+			entryState, known := state[id]
+			if !known || entryState.Hash != hash {
+				entryState = feedEntryState{Hash: hash, Updated: now}
+				state[id] = entryState
+			}
+			if entryState.Updated.After(updated) {
+				updated = entryState.Updated
+			}
+
+			items = append(items, feedItem{
+				ID:      id,
+				Title:   fmt.Sprintf("%s – %s, KW %d", source.Name, day.Name, week),
+				HTML:    content,
+				Updated: entryState.Updated,
+			})
+		}
+	}
+	if updated.IsZero() {
+		updated = now
+	}
+	if err := saveFeedState(state); err != nil {
+		return "", nil, time.Time{}, err
+	}
+	return tagPrefix + "menu", items, updated, nil
+}
+
+// renderAtom renders an Atom feed with one entry per weekday and canteen.
+func renderAtom(days []DayMenus, week WeekMenu) ([]byte, error) {
+	feedID, items, updated, err := buildFeedItems(days, week)
+	if err != nil {
+		return nil, err
+	}
+	feed := atomFeed{
+		ID:      feedID,
+		Title:   feedTitle,
+		Updated: updated.Format(time.RFC3339),
+		Author:  atomAuthor{Name: feedTitle},
+		Links:   []atomLink{{Href: siteURL}},
+	}
+	for _, item := range items {
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      item.ID,
+			Title:   item.Title,
+			Updated: item.Updated.Format(time.RFC3339),
+			Link:    atomLink{Href: siteURL},
+			Content: atomContent{Type: "html", Body: item.HTML},
+		})
+	}
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling feed: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// menuViewHTML renders the categories of a menu as a small HTML fragment
+// for feed entries.
+func menuViewHTML(menu MenuView) string {
+	var b strings.Builder
+	for _, annotation := range menu.Annotations {
+		fmt.Fprintf(&b, "<p><em>%s</em></p>", html.EscapeString(annotation.String()))
+	}
+	for _, category := range menu.Categories {
+		fmt.Fprintf(&b, "<h3>%s</h3><ul>", html.EscapeString(category.Name))
+		for _, dish := range category.Dishes {
+			b.WriteString("<li>" + html.EscapeString(dish.Title))
+			if dish.Price != "" {
+				b.WriteString(" – " + html.EscapeString(dish.Price))
+			}
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul>")
+	}
+	return b.String()
+}
+
+func feedStatePath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "feed-state.json"), nil
+}
+
+func loadFeedState() (map[string]feedEntryState, error) {
+	state := make(map[string]feedEntryState)
+	path, err := feedStatePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading feed state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error decoding feed state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// saveFeedState stores the entry state, dropping entries older than eight
+// weeks so the file doesn't grow forever.
+func saveFeedState(state map[string]feedEntryState) error {
+	cutoff := time.Now().AddDate(0, 0, -8*7)
+	for id, entry := range state {
+		if entry.Updated.Before(cutoff) {
+			delete(state, id)
+		}
+	}
+	path, err := feedStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing feed state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFeedIDsAcrossYears(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	plans := samplePlans(t)
+	var feedIDs []string
+	for _, year := range []int{2025, 2026} {
+		jku := plans["jku"]
+		jku.Year = year
+		week := buildWeekMenu(jku)
+		feedID, items, _, err := buildFeedItems(buildDayMenus(week), week)
+		if err != nil {
+			t.Fatal(err)
+		}
+		feedIDs = append(feedIDs, feedID)
+		if len(items) == 0 {
+			t.Fatal("no entries")
+		}
+		if id := items[0].ID; !strings.HasPrefix(id, "tag:menu.krenn.dev,") || !strings.Contains(id, "-W45-") {
+			t.Errorf("entry id %q, want one derived from the week", id)
+		}
+	}
+	if feedIDs[0] != feedIDs[1] {
+		t.Errorf("feed ids %q, want the same in every year", feedIDs)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// sourceSetting is a flag setting a property of each source from a list like
+// "jku=#0b6e4f,khg=#8a1538". The values are checked when the flag is parsed
+// but applied by applyBranding, once -location and -static have added
+// their sources.
+type sourceSetting struct {
+	Name  string
+	Usage string
+	Apply func(s *menuSource, value string) error
+}
+
+// brandingValue is a value of a sourceSetting for one source.
+type brandingValue struct {
+	setting sourceSetting
+	id      string
+	value   string
+}
+
+// brandingValues are the parsed values of the branding flags, in order.
+var brandingValues []brandingValue
+
+func (f sourceSetting) String() string { return "" }
+
+func (f sourceSetting) Set(list string) error {
+	for _, pair := range splitSourceList(list) {
+		id, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("invalid %s %q, want source=value", f.Name, pair)
+		}
+		if err := f.Apply(&menuSource{}, value); err != nil {
+			return err
+		}
+		brandingValues = append(brandingValues, brandingValue{f, id, value})
+	}
+	return nil
+}
+
+// applyBranding applies the branding flags to the known sources.
+func applyBranding() error {
+	for _, b := range brandingValues {
+		i := slices.IndexFunc(knownSources, func(s menuSource) bool { return s.ID == b.id })
+		if i < 0 {
+			return fmt.Errorf("unknown source %q in %s, want one of %s", b.id, b.setting.Name, strings.Join(sourceIDs(), ", "))
+		}
+		if err := b.setting.Apply(&knownSources[i], b.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitSourceList splits list at its commas, except the one separating the
+// header and data of a data URI, as in "jku=data:image/png;base64,iVBO…".
+func splitSourceList(list string) []string {
+	var pairs []string
+	for _, part := range strings.Split(list, ",") {
+		if n := len(pairs); n > 0 {
+			_, value, _ := strings.Cut(pairs[n-1], "=")
+			if strings.HasPrefix(strings.TrimSpace(value), "data:") && !strings.Contains(value, ",") {
+				pairs[n-1] += "," + part
+				continue
+			}
+		}
+		pairs = append(pairs, part)
+	}
+	return pairs
+}
+
+// brandingFlags are the flags customizing how sources are presented.
+var brandingFlags = map[string]sourceSetting{
+	"source-color": {"source color", "Accent color per source, e.g. jku=#0b6e4f,khg=#8a1538", func(s *menuSource, value string) error {
+		if _, err := parseHexColor(value); err != nil {
+			return err
+		}
+		s.Color = value
+		return nil
+	}},
+	"source-short": {"short name", "Short name per source for narrow layouts, e.g. jku=Mensa", func(s *menuSource, value string) error {
+		s.Short = value
+		return nil
+	}},
+	"source-logo": {"logo", "Logo https URL or data:image/ URI per source for the HTML layouts", func(s *menuSource, value string) error {
+		logo, err := validateLogo(value)
+		if err != nil {
+			return err
+		}
+		s.Logo = logo
+		return nil
+	}},
+}
+
+// validateLogo accepts https URLs and data:image/ URIs, which the HTML
+// layouts can use as the src of an image as they are.
+func validateLogo(value string) (string, error) {
+	if data, ok := strings.CutPrefix(value, "data:image/"); ok {
+		if !strings.Contains(data, ",") || strings.ContainsAny(data, "\"'<> \t\r\n") {
+			return "", fmt.Errorf("invalid logo data URI %.40q", value)
+		}
+		return value, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("invalid logo %q, want an https URL or a data:image/ URI", value)
+	}
+	return u.String(), nil
+}
+
+// sourceColor returns the accent color of a source for the image and PDF
+// renderers.
+func sourceColor(source SourceView) color.RGBA {
+	c, err := parseHexColor(source.Color)
+	if err != nil {
+		return imageAccent
+	}
+	return c
+}
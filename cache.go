@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cachedPlanPath returns where the last good plan of a source is kept.
+func cachedPlanPath(sourceID string) (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plan-"+sourceID+".json"), nil
+}
+
+// nextWeekCacheID is the ID next week's plan of a source is cached under.
+func nextWeekCacheID(sourceID string) string {
+	return sourceID + "-next"
+}
+
+// loadCachedNextWeek returns the cached next week's plans of sources, with
+// empty plans for those without one. Plans cached before this week began
+// are for this week or earlier and count as missing.
+func loadCachedNextWeek() []MenuPlan {
+	next := make([]MenuPlan, len(sources))
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		log.Printf("Error loading time zone: %v", err)
+		return next
+	}
+	year, week := time.Now().In(vienna).AddDate(0, 0, 7).ISOWeek()
+	for i, s := range sources {
+		plan, err := loadCachedPlan(nextWeekCacheID(s.ID))
+		if err != nil || plan.Location != s.Location {
+			continue
+		}
+		if start, ok := plan.WeekStart(); ok {
+			if planYear, planWeek := start.ISOWeek(); planYear == year && planWeek == week {
+				next[i] = plan
+			}
+		}
+	}
+	return next
+}
+
+// saveCachedPlan stores plan as the last good plan of a source.
+func saveCachedPlan(sourceID string, plan MenuPlan) error {
+	path, err := cachedPlanPath(sourceID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("error marshaling cached plan: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing cached plan: %w", err)
+	}
+	return nil
+}
+
+// planForDay returns a plan of source covering day and the day's key in it.
+// The cached plan is used if it covers the day, so commands about a single
+// day don't need network access.
+func planForDay(source menuSource, day time.Time) (MenuPlan, Weekday, bool) {
+	plan, err := loadCachedPlan(source.ID)
+	weekday, inWeek := plan.Day(day)
+	if err == nil && inWeek {
+		return plan, weekday, true
+	}
+	if plan, err = source.Source.Fetch(context.Background()); err != nil && plan.DishCount() == 0 {
+		return MenuPlan{}, 0, false
+	}
+	weekday, inWeek = plan.Day(day)
+	return plan, weekday, inWeek
+}
+
+// loadCachedPlan returns the last good plan of a source.
+func loadCachedPlan(sourceID string) (MenuPlan, error) {
+	path, err := cachedPlanPath(sourceID)
+	if err != nil {
+		return MenuPlan{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MenuPlan{}, err
+	}
+	var plan MenuPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return MenuPlan{}, fmt.Errorf("error decoding cached plan %s: %w", path, err)
+	}
+	return plan, nil
+}
@@ -0,0 +1,124 @@
+// Package client reads menus from a server started with
+// `go-menu-extractor serve`, so display projects get typed data instead of
+// decoding the JSON output by hand.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The types below mirror the JSON output of the json format. Fields are
+// only ever added to it, so older clients keep working with newer servers.
+
+// Week is the menus of one week.
+type Week struct {
+	Year int   `json:"year"`
+	Week int   `json:"week"`
+	Days []Day `json:"days"`
+}
+
+// Day is the menus of all canteens on one day.
+type Day struct {
+	Weekday      int      `json:"weekday"` // 1 = Monday … 7 = Sunday
+	Name         string   `json:"name"`
+	Date         string   `json:"date"` // YYYY-MM-DD
+	Closed       bool     `json:"closed"`
+	ClosedReason string   `json:"closed_reason"`
+	Sources      []Source `json:"sources"`
+}
+
+// Source is the menu of one canteen on one day.
+type Source struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Categories  []Category   `json:"categories"`
+	Annotations []Annotation `json:"annotations"`
+}
+
+type Category struct {
+	Name   string `json:"name"`
+	Dishes []Dish `json:"dishes"`
+}
+
+type Dish struct {
+	Title      string `json:"title"`
+	Price      string `json:"price"`       // as published by the canteen, may be empty
+	PriceCents *int   `json:"price_cents"` // nil if the price can't be parsed
+}
+
+// Annotation is a notice about a canteen's data, e.g. that the server fell
+// back to its cache.
+type Annotation struct {
+	Level   string `json:"level"` // "info" or "warning"
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+// Client is a client for one menu server.
+type Client struct {
+	BaseURL    string       // e.g. "http://localhost:8080"
+	HTTPClient *http.Client // nil means http.DefaultClient
+}
+
+// New returns a client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Week returns the menus of the displayed week.
+func (c *Client) Week(ctx context.Context) (*Week, error) {
+	var week Week
+	if err := c.get(ctx, "/json", nil, &week); err != nil {
+		return nil, err
+	}
+	return &week, nil
+}
+
+// Day returns the menus of one day: "today", "tomorrow", a weekday name or
+// a date as YYYY-MM-DD. Like the -day flag, a day the server doesn't
+// display falls forward to the next one that it does.
+func (c *Client) Day(ctx context.Context, day string) (*Day, error) {
+	var week Week
+	if err := c.get(ctx, "/json", url.Values{"day": {day}}, &week); err != nil {
+		return nil, err
+	}
+	if len(week.Days) == 0 {
+		return nil, fmt.Errorf("no menus for %s", day)
+	}
+	return &week.Days[0], nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding %s: %w", u, err)
+	}
+	return nil
+}
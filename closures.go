@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// closedDates maps the dates on which the canteens are closed, such as the
+// rector's day or building maintenance, to the reason, which may be empty.
+// Set with -closed.
+var closedDates = map[Date]string{}
+
+// loadClosedDates reads a closure list: one date per line as YYYY-MM-DD,
+// optionally followed by the reason. Blank lines and lines starting with #
+// are ignored.
+func loadClosedDates(path string) (map[Date]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading closure list: %w", err)
+	}
+	defer f.Close()
+
+	dates := map[Date]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		value, reason, _ := strings.Cut(line, " ")
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid date %q", path, n, value)
+		}
+		dates[DateOf(t)] = strings.TrimSpace(reason)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading closure list: %w", err)
+	}
+	return dates, nil
+}
+
+// closureNote returns the text shown instead of the menu on a closed day.
+func closureNote(reason string) string {
+	if reason == "" {
+		return "Closed"
+	}
+	return "Closed: " + reason
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfig is the -config value that reads config.yaml from the user
+// config directory if it exists.
+const defaultConfig = "default"
+
+// parseFlags parses the command-line arguments of a command like
+// fs.Parse, after setting its flags from the config file and then from
+// JKU_MENU_* environment variables (see applyEnv). The config file maps
+// flag names to values:
+//
+//	format: html,json
+//	days: [mon, tue, wed, thu]
+//	source-color: {jku: "#0b6e4f"}
+//	serve:
+//	  addr: :8080
+//
+// Lists are joined with commas and maps are written as key=value pairs.
+// Top-level keys apply to every command that has the flag and are ignored
+// by the others; a section named after a command ("run" for a plain run)
+// only applies to that command. Flags given on the command line win.
+func parseFlags(flagSet *flag.FlagSet, args []string) error {
+	configPath := flagSet.String("config", defaultConfig, "YAML config file setting flags (\"default\": config.yaml in the user config directory, or next to the executable with -portable; empty: none)")
+	// -portable decides where the config file is, so it is set first.
+	if flagSet.Lookup("portable") != nil {
+		value, ok := findFlag(args, "portable", true)
+		if !ok {
+			value, ok = os.LookupEnv(envName("portable"))
+		}
+		if ok {
+			if err := flagSet.Set("portable", value); err != nil {
+				return fmt.Errorf("invalid value %q for -portable: %w", value, err)
+			}
+		}
+	}
+	path, explicit := findFlag(args, "config", false)
+	if !explicit {
+		path = *configPath
+		if value, ok := os.LookupEnv(envName("config")); ok {
+			path = value
+		}
+	}
+	config, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	section := flagSet.Name()
+	if flagSet == flag.CommandLine {
+		section = "run"
+	}
+	if err := applyConfig(flagSet, config, false); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if values, ok := config[section].(map[string]any); ok && flagSet.Lookup(section) == nil {
+		if err := applyConfig(flagSet, values, true); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, section, err)
+		}
+	}
+	if err := applyEnv(flagSet); err != nil {
+		return err
+	}
+	return flagSet.Parse(args)
+}
+
+// envPrefix prefixes the environment variables that set flags.
+const envPrefix = "JKU_MENU_"
+
+// envName returns the environment variable setting the flag name, e.g.
+// JKU_MENU_WEEK_START for -week-start.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnv sets the flags of flagSet from their environment variables, so
+// containers can be configured without a config file. Values are given as
+// on the command line.
+func applyEnv(flagSet *flag.FlagSet) error {
+	var err error
+	flagSet.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || err != nil {
+			return
+		}
+		if setErr := flagSet.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value for %s: %w", envName(f.Name), setErr)
+		}
+	})
+	return err
+}
+
+// findFlag returns the value of the flag name in args, for the flags that
+// have to be known before the others are parsed. A boolean flag given
+// without a value is "true".
+func findFlag(args []string, name string, boolean bool) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || flagName != name {
+			continue
+		}
+		switch {
+		case hasValue:
+			return value, true
+		case boolean:
+			return "true", true
+		case i+1 < len(args):
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// loadConfig reads the config file at path; see parseFlags. The default
+// one is config.yaml in dataDir, and a relative path is resolved like the
+// other paths.
+func loadConfig(path string) (map[string]any, error) {
+	if path == "" {
+		return nil, nil
+	}
+	optional := path == defaultConfig
+	if optional {
+		dir, err := dataDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(dir, "config.yaml")
+	} else {
+		var err error
+		if path, err = resolvePath(path); err != nil {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(path)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+	var config map[string]any
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// applyConfig sets the flags of flagSet named in values. Unknown names are
+// errors if strict, and skipped otherwise.
+func applyConfig(flagSet *flag.FlagSet, values map[string]any, strict bool) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "config" {
+			continue
+		}
+		if flagSet.Lookup(name) == nil {
+			if strict {
+				return fmt.Errorf("unknown flag %q", name)
+			}
+			continue
+		}
+		if err := flagSet.Set(name, configValue(values[name])); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// configValue converts a YAML value to the string form of a flag value.
+func configValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = configValue(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = key + "=" + configValue(v[key])
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// dumpCommand implements the dump command: it fetches one or all sources
+// and prints the parsed MenuPlan as JSON, so parser problems can be reported
+// with the data the renderer actually sees.
+func dumpCommand(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	source := fs.String("source", "all", "Source to dump: "+strings.Join(sourceIDs(), ", ")+" or all")
+	pretty := fs.Bool("pretty", false, "Indent the JSON output")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	plans := make(map[string]MenuPlan)
+	var failed []string
+	for _, s := range sources {
+		if *source != "all" && *source != s.ID {
+			continue
+		}
+		plan, err := s.Source.Fetch(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching %s menu: %v\n", s.Name, err)
+			failed = append(failed, s.ID)
+		}
+		plans[s.ID] = plan
+	}
+	if len(plans) == 0 {
+		return fmt.Errorf("unknown source %q", *source)
+	}
+
+	var v interface{} = plans
+	if *source != "all" {
+		v = plans[*source]
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	if *pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to fetch %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// renderEinkImage draws today's menus in black and white with large type for
+// e-paper panels, as a 1-bit PNG or, if raw is set, as a packed bitmap.
+func renderEinkImage(day DayMenus, size imageSize, raw bool) ([]byte, error) {
+	img, err := drawFitted(size, func(scale, fontSize float64) (imageLayout, error) {
+		var (
+			width  = size.Width
+			margin = int(24 * scale * 2)
+			gutter = int(24 * scale * 2)
+		)
+		// Small panels need larger type than screens to stay readable, and
+		// thin strokes don't survive the conversion to 1 bit.
+		fonts, err := loadImageFonts(fontSize * 2.2)
+		if err != nil {
+			return imageLayout{}, fmt.Errorf("error loading fonts: %w", err)
+		}
+		canteens := day.Canteens()
+		colWidth := (width - 2*margin - (len(canteens)-1)*gutter) / len(canteens)
+
+		header := &textBlock{Width: width - 2*margin}
+		header.add(fonts.Heading, color.Black, 0, fmt.Sprintf("%s, %s", day.Name, day.Date.Format("02.01.")))
+
+		var blocks []*textBlock
+		bodyHeight := 0
+		for _, menu := range canteens {
+			block := &textBlock{Width: colWidth}
+			block.add(fonts.Heading, color.Black, 0, menu.Source.Name)
+			for _, annotation := range menu.Annotations {
+				block.add(fonts.Body, color.Black, gutter/2, annotation.String())
+			}
+			switch {
+			case menu.Closed != "":
+				block.add(fonts.Body, color.Black, gutter/2, menu.Closed)
+			case len(menu.Categories) == 0:
+				block.add(fonts.Body, color.Black, gutter/2, "No menu data")
+			}
+			for _, category := range menu.Categories {
+				block.add(fonts.Bold, color.Black, gutter/2, category.Name)
+				for _, dish := range category.Dishes {
+					text := dish.Title
+					if dish.Price != "" {
+						text += " – " + dish.Price
+					}
+					block.add(fonts.Body, color.Black, 0, text)
+				}
+			}
+			blocks = append(blocks, block)
+			bodyHeight = max(bodyHeight, block.height())
+		}
+		rule := max(int(2*scale*2), 1)
+		height := margin + header.height() + gutter/2 + rule + gutter/2 + bodyHeight + margin
+
+		return imageLayout{Height: height, Draw: func(img *image.RGBA) {
+			fillRect(img, img.Bounds(), color.White)
+			header.draw(img, margin, margin)
+			y := margin + header.height() + gutter/2
+			fillRect(img, image.Rect(margin, y, width-margin, y+rule), color.Black)
+			y += rule + gutter/2
+			for i, block := range blocks {
+				x := margin + i*(colWidth+gutter)
+				if i > 0 {
+					fillRect(img, image.Rect(x-gutter/2-rule/2, y, x-gutter/2+rule-rule/2, y+bodyHeight), color.Black)
+				}
+				block.draw(img, x, y)
+			}
+		}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	mono := toMonochrome(img)
+	if raw {
+		return packBits(mono), nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, mono); err != nil {
+		return nil, fmt.Errorf("error encoding PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// toMonochrome converts img to black and white by thresholding luminance.
+func toMonochrome(img image.Image) *image.Paletted {
+	bounds := img.Bounds()
+	mono := image.NewPaletted(bounds, color.Palette{color.Black, color.White})
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y >= 0x80 {
+				mono.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return mono
+}
+
+// packBits returns the image as rows of bits, most significant bit first,
+// with 1 for white and 0 for black and each row padded to whole bytes. This
+// is the frame buffer layout common e-paper drivers expect.
+func packBits(img *image.Paletted) []byte {
+	bounds := img.Bounds()
+	stride := (bounds.Dx() + 7) / 8
+	out := make([]byte, stride*bounds.Dy())
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if img.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+y) == 1 {
+				out[y*stride+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+	return out
+}
@@ -1,189 +1,170 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"flag"
 	"fmt"
-	"io"
 	"net/http"
-	"regexp"
-	"strconv"
+	"path"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"krenn.dev/menu/pkg/menu"
 )
 
-const (
-	jkuMensaURL = "https://backend.mensen.at/api"
-	khgMenuURL  = "https://www.dioezese-linz.at/khg/mensa/menueplan"
-)
-
-func fetchJKUMensa() (MenuPlan, error) {
-	apiUrl := jkuMensaURL
-	query := `query Location($locationUri: String!, $weekDay: String!) {
-	  nodeByUri(uri: $locationUri) {
-		... on Location {
-		  menuplanCurrentWeek
-		  openingHour(day: $weekDay) {
-			nowDate
-			nowWeekDay
-			status
-			from
-			to
-			closed
-			reopen
-		  }
-		  title
-		  uri
-		}
-	  }
-	}`
-
-	payload := GraphQLRequest{
-		Query: query,
-		Variables: Variables{
-			LocationURI: "standort/mensa-jku/",
-			WeekDay:     "now",
-		},
-		OperationName: "Location",
-	}
+// jkuLocation is the mensen.at location of the JKU Mensa.
+const jkuLocation = "standort/mensa-jku/"
+
+// defaultSourceColor is the accent color of sources without one of their own.
+const defaultSourceColor = "#f59e42"
+
+// httpClient is shared by all sources, so -timeout applies to each of them.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// menuSource is a menu shown on the page.
+type menuSource struct {
+	ID       string // short identifier used on the command line
+	Name     string
+	Short    string // abbreviation for narrow layouts, defaults to Name
+	Location string // mensen.at location URI, for sources fetched from mensen.at
+	// PublishedName shows the name published by the source, if any,
+	// instead of Name.
+	PublishedName bool
+	Color         string // accent color as CSS hex color
+	Logo          string // https URL or data:image/ URI, shown by the HTML layouts
+	// Source fetches the plans. A new canteen is added by implementing
+	// menu.Source and listing it in sources; the renderers show every
+	// source in that list.
+	Source menu.Source
+}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return MenuPlan{}, fmt.Errorf("error marshaling request payload: %w", err)
-	}
+// knownSources lists all menu sources in their default display order.
+var knownSources = []menuSource{
+	{ID: "jku", Name: "JKU Mensa", Short: "JKU", Location: jkuLocation, Color: defaultSourceColor, Source: menu.MensenAt{Location: jkuLocation, Client: httpClient}},
+	{ID: "khg", Name: "KHG", Short: "KHG", Color: defaultSourceColor, Source: menu.NewKHG(httpClient)},
+}
 
-	req, err := http.NewRequest("POST", apiUrl, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return MenuPlan{}, fmt.Errorf("error creating HTTP request: %w", err)
-	}
+// sources are the shown sources in display order, all known ones unless
+// -sources selects others.
+var sources = knownSources
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+// sourceOptions are the flags choosing the sources. Rendering from the
+// cache needs them as much as fetching, so the plans line up with sources.
+type sourceOptions struct {
+	location string
+	static   string
+	sources  string
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return MenuPlan{}, fmt.Errorf("error sending HTTP request: %w", err)
-	}
-	defer resp.Body.Close()
+func addSourceFlags(fs *flag.FlagSet) *sourceOptions {
+	opts := &sourceOptions{}
+	fs.StringVar(&opts.location, "location", "", "mensen.at location URIs to show, separated by commas, e.g. standort/mensa-jku/,standort/mensa-khg/: the first replaces the JKU Mensa, the others are added (default: the JKU Mensa)")
+	fs.StringVar(&opts.static, "static", "", "Static menu files to add as sources, e.g. kebab=kebab.yaml,sushi=sushi.yaml")
+	fs.StringVar(&opts.sources, "sources", "", sourcesUsage())
+	return opts
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return MenuPlan{}, fmt.Errorf("error reading response body: %w", err)
+// apply configures the known sources and then selects the shown ones.
+func (opts *sourceOptions) apply() error {
+	if opts.location != "" {
+		if err := setLocations(opts.location); err != nil {
+			return err
+		}
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return MenuPlan{}, fmt.Errorf("API request failed with status: %s\nResponse: %s", resp.Status, string(body))
+	if opts.static != "" {
+		if err := addStaticSources(opts.static); err != nil {
+			return err
+		}
 	}
-
-	var apiResponse APIResponse
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return MenuPlan{}, fmt.Errorf("error unmarshaling outer JSON: %w\nBody: %s", err, string(body))
+	if err := applyBranding(); err != nil {
+		return err
 	}
-
-	var currentWeekMenu MenuPlan
-	menuString := apiResponse.Data.NodeByUri.MenuplanCurrentWeek
-	if err := json.Unmarshal([]byte(menuString), &currentWeekMenu); err != nil {
-		return MenuPlan{}, fmt.Errorf("error unmarshaling inner menu JSON: %w\nString was: %s", err, menuString)
+	if opts.sources == "" {
+		sources = knownSources
+		return nil
 	}
-
-	return currentWeekMenu, nil
+	return selectSources(opts.sources)
 }
 
-// getDayKey converts the German day name to a numeric string key.
-func getDayKey(day string) string {
-	switch strings.ToLower(day) {
-	case "montag":
-		return "1"
-	case "dienstag":
-		return "2"
-	case "mittwoch":
-		return "3"
-	case "donnerstag":
-		return "4"
-	case "freitag":
-		return "5"
-	case "samstag":
-		return "6"
-	case "sonntag":
-		return "7"
-	default:
-		return "" // Invalid day
+func sourceIDs() []string {
+	ids := make([]string, len(knownSources))
+	for i, s := range knownSources {
+		ids[i] = s.ID
 	}
+	return ids
 }
 
-var (
-	reWeek = regexp.MustCompile(`KW (\d+)`)
-	reYear = regexp.MustCompile(`(\d{4})`)
-)
-
-func fetchKHGMenu() (MenuPlan, error) {
-	url := khgMenuURL
-	res, err := http.Get(url)
-	if err != nil {
-		return MenuPlan{}, fmt.Errorf("failed to fetch URL %s: %w", url, err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return MenuPlan{}, fmt.Errorf("bad status code: %d", res.StatusCode)
-	}
-
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		return MenuPlan{}, fmt.Errorf("failed to parse HTML: %w", err)
-	}
-
-	menuPlan := MenuPlan{
-		Menus: []MenuCategory{
-			{Name: "Menü 1", Menus: make(map[string][]Dish)},
-			{Name: "Menü 2", Menus: make(map[string][]Dish)},
-		},
-	}
-
-	headerText := doc.Find(".swslang h4").First().Text()
+// sourcesUsage is the help of the -sources flag.
+func sourcesUsage() string {
+	return "Sources to show, in display order: " + strings.Join(sourceIDs(), ", ") + " or a -static one (default: all)"
+}
 
-	if weekMatches := reWeek.FindStringSubmatch(headerText); len(weekMatches) > 1 {
-		menuPlan.Week = weekMatches[1]
-	}
-	if yearMatches := reYear.FindStringSubmatch(headerText); len(yearMatches) > 1 {
-		if year, err := strconv.Atoi(yearMatches[1]); err == nil {
-			menuPlan.Year = year
+// selectSources sets sources to the known sources listed by ID, e.g.
+// "khg,jku". Sources are configured before they are selected.
+func selectSources(list string) error {
+	var selected []menuSource
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		i := slices.IndexFunc(knownSources, func(s menuSource) bool { return s.ID == id })
+		if i < 0 {
+			return fmt.Errorf("unknown source %q, want one of %s", id, strings.Join(sourceIDs(), ", "))
+		}
+		if !slices.ContainsFunc(selected, func(s menuSource) bool { return s.ID == id }) {
+			selected = append(selected, knownSources[i])
 		}
 	}
+	sources = selected
+	return nil
+}
 
-	// Process the menu table
-	var currentDayKey string
-	var dishCounterForDay int // 0 for Menü 1, 1 for Menü 2
-
-	doc.Find("table.sweTable1 tbody tr").Each(func(i int, row *goquery.Selection) {
-
-		// Day header row (e.g., "Montag")
-		if row.HasClass("sweTableRow1") {
-			dayName := row.Find("strong").Text()
-			currentDayKey = getDayKey(dayName)
-			dishCounterForDay = 0
-			return
+// setLocations shows the mensen.at locations of list, like
+// "standort/mensa-jku/,standort/mensa-khg/". The first replaces the JKU
+// Mensa, unless it is the JKU Mensa; each further one is added as a source
+// named after the location's title, with the last part of its URI as ID,
+// e.g. "mensa-khg". All of them share httpClient and are fetched
+// concurrently.
+func setLocations(list string) error {
+	for i, uri := range strings.Split(list, ",") {
+		uri = strings.Trim(strings.TrimSpace(uri), "/")
+		if uri == "" {
+			return fmt.Errorf("empty location in %q", list)
 		}
-
-		// Dish row: has 3 <td> children
-		cells := row.Find("td")
-		if cells.Length() == 3 && currentDayKey != "" {
-			title := strings.TrimSpace(cells.Eq(0).Text())
-			price := strings.TrimSpace(cells.Eq(1).Text())
-			dish := Dish{
-				TitleDe: title,
-				Price:   price,
-			}
-			if dishCounterForDay < len(menuPlan.Menus) {
-				category := &menuPlan.Menus[dishCounterForDay]
-				category.Menus[currentDayKey] = append(category.Menus[currentDayKey], dish)
-				dishCounterForDay++
+		uri += "/"
+		if i == 0 {
+			if uri != jkuLocation {
+				setLocation(uri)
 			}
+			continue
 		}
-	})
+		if slices.ContainsFunc(knownSources, func(s menuSource) bool { return s.Location == uri }) {
+			return fmt.Errorf("location %s is listed twice", uri)
+		}
+		id := path.Base(uri)
+		if slices.ContainsFunc(knownSources, func(s menuSource) bool { return s.ID == id }) {
+			return fmt.Errorf("location %s would get the source ID %q, which is already taken", uri, id)
+		}
+		knownSources = append(knownSources, menuSource{
+			ID:            id,
+			Name:          uri,
+			Location:      uri,
+			PublishedName: true,
+			Color:         defaultSourceColor,
+			Source:        menu.MensenAt{Location: uri, Client: httpClient},
+		})
+	}
+	return nil
+}
 
-	return menuPlan, nil
+// setLocation makes the jku source read the mensen.at location uri instead
+// of the JKU Mensa. Its name is then taken from the location's title, and
+// so is its short name unless -source-short sets one: the branding flags
+// are applied after the locations.
+func setLocation(uri string) {
+	uri = strings.Trim(uri, "/") + "/"
+	jku := &knownSources[0]
+	jku.Location = uri
+	jku.Source = menu.MensenAt{Location: uri, Client: httpClient}
+	jku.Name = uri
+	jku.Short = ""
+	jku.PublishedName = true
 }
@@ -2,7 +2,13 @@ module krenn.dev/menu
 
 go 1.23.0
 
-require github.com/PuerkitoBio/goquery v1.10.3
+require (
+	github.com/PuerkitoBio/goquery v1.10.3
+	github.com/jung-kurt/gofpdf v1.16.2
+	golang.org/x/image v0.25.0
+	golang.org/x/text v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/andybalholm/cascadia v1.3.3 // indirect
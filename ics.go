@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Europe/Vienna must resolve on systems without a zoneinfo database
+)
+
+// Lunch events span the canteens' main serving time.
+const (
+	icsLunchStart = 11*time.Hour + 30*time.Minute
+	icsLunchEnd   = 13*time.Hour + 30*time.Minute
+)
+
+// renderICS renders an iCalendar file with one lunch event per weekday that
+// lists the dishes of all canteens in its description. days may span more
+// than one week; events are identified by their date, so each keeps its UID
+// from one week's calendar to the next.
+func renderICS(days []DayMenus) ([]byte, error) {
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		return nil, fmt.Errorf("error loading time zone: %w", err)
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//krenn.dev//JKU Mensa & KHG Menu//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "X-WR-CALNAME:JKU Mensa & KHG Menu")
+	for _, day := range days {
+		date := time.Date(day.Date.Year(), day.Date.Month(), day.Date.Day(), 0, 0, 0, 0, vienna)
+		start := date.Add(icsLunchStart)
+		end := date.Add(icsLunchEnd)
+
+		var description []string
+		for _, menu := range day.Canteens() {
+			description = append(description, menu.Source.Name+":")
+			for _, annotation := range menu.Annotations {
+				description = append(description, annotation.String())
+			}
+			switch {
+			case menu.Closed != "":
+				description = append(description, menu.Closed)
+			case len(menu.Categories) == 0:
+				description = append(description, "No menu data")
+			}
+			for _, category := range menu.Categories {
+				for _, dish := range category.Dishes {
+					line := "- " + category.Name + ": " + dish.Title
+					if dish.Price != "" {
+						line += " (" + dish.Price + ")"
+					}
+					description = append(description, line)
+				}
+			}
+			description = append(description, "")
+		}
+
+		year, week := day.Date.ISOWeek()
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, fmt.Sprintf("UID:%d-W%02d-%d@menu.krenn.dev", year, week, weekdayOf(day.Date)))
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		writeICSLine(&b, "DTSTART:"+start.UTC().Format("20060102T150405Z"))
+		writeICSLine(&b, "DTEND:"+end.UTC().Format("20060102T150405Z"))
+		writeICSLine(&b, "SUMMARY:"+escapeICSText("Lunch menu "+day.Name))
+		writeICSLine(&b, "DESCRIPTION:"+escapeICSText(strings.TrimSpace(strings.Join(description, "\n"))))
+		writeICSLine(&b, "TRANSP:TRANSPARENT")
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	return []byte(b.String()), nil
+}
+
+// escapeICSText escapes a TEXT property value (RFC 5545, 3.3.11).
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line, folded at 75 octets without splitting
+// UTF-8 sequences (RFC 5545, 3.1).
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isUTF8Start(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts too.
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
+
+func isUTF8Start(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Colors match the variables of the HTML template.
+var (
+	imageBackground = color.RGBA{0xf5, 0xf7, 0xfa, 0xff}
+	imageCard       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	imagePrimary    = color.RGBA{0x22, 0x2c, 0x36, 0xff}
+	imageAccent     = color.RGBA{0xf5, 0x9e, 0x42, 0xff}
+	imageMuted      = color.RGBA{0x8a, 0x94, 0x9e, 0xff}
+)
+
+// imageFonts are the faces used by the image renderers, sized relative to a
+// base size so layouts can be scaled.
+type imageFonts struct {
+	Title   font.Face
+	Heading font.Face
+	Body    font.Face
+	Bold    font.Face
+}
+
+func loadImageFonts(size float64) (imageFonts, error) {
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return imageFonts{}, err
+	}
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return imageFonts{}, err
+	}
+	face := func(f *opentype.Font, size float64) (font.Face, error) {
+		return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	}
+	var fonts imageFonts
+	if fonts.Title, err = face(bold, size*2); err != nil {
+		return imageFonts{}, err
+	}
+	if fonts.Heading, err = face(bold, size*1.3); err != nil {
+		return imageFonts{}, err
+	}
+	if fonts.Body, err = face(regular, size); err != nil {
+		return imageFonts{}, err
+	}
+	if fonts.Bold, err = face(bold, size); err != nil {
+		return imageFonts{}, err
+	}
+	return fonts, nil
+}
+
+// textLine is a single line of laid out text.
+type textLine struct {
+	Text  string
+	Face  font.Face
+	Color color.Color
+	Gap   int // extra space above the line
+}
+
+// textBlock is a column of lines wrapped to a fixed width.
+type textBlock struct {
+	Width int
+	Lines []textLine
+}
+
+// add wraps text to the block's width and appends the resulting lines.
+func (b *textBlock) add(face font.Face, c color.Color, gap int, text string) {
+	for i, line := range wrapText(face, text, b.Width) {
+		if i > 0 {
+			gap = 0
+		}
+		b.Lines = append(b.Lines, textLine{Text: line, Face: face, Color: c, Gap: gap})
+	}
+}
+
+func (b *textBlock) height() int {
+	h := 0
+	for _, line := range b.Lines {
+		h += line.Gap + line.Face.Metrics().Height.Ceil()
+	}
+	return h
+}
+
+// draw renders the block with its top left corner at (x, y).
+func (b *textBlock) draw(dst draw.Image, x, y int) {
+	for _, line := range b.Lines {
+		y += line.Gap
+		d := font.Drawer{
+			Dst:  dst,
+			Src:  image.NewUniform(line.Color),
+			Face: line.Face,
+			Dot:  fixed.P(x, y+line.Face.Metrics().Ascent.Ceil()),
+		}
+		d.DrawString(line.Text)
+		y += line.Face.Metrics().Height.Ceil()
+	}
+}
+
+// wrapText breaks text into lines no wider than width. Words longer than a
+// line are put on a line of their own.
+func wrapText(face font.Face, text string, width int) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && font.MeasureString(face, candidate).Ceil() > width {
+			lines = append(lines, current)
+			candidate = word
+		}
+		current = candidate
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// menuBlock lays out the categories and dishes of one canteen on one day.
+func menuBlock(fonts imageFonts, menu MenuView, width int) *textBlock {
+	block := &textBlock{Width: width}
+	for _, annotation := range menu.Annotations {
+		block.add(fonts.Body, imageMuted, 0, annotation.String())
+	}
+	if menu.Closed != "" {
+		block.add(fonts.Body, imageMuted, 0, menu.Closed)
+		return block
+	}
+	if len(menu.Categories) == 0 {
+		block.add(fonts.Body, imageMuted, 0, "No menu data")
+		return block
+	}
+	for i, category := range menu.Categories {
+		gap := 0
+		if i > 0 || len(menu.Annotations) > 0 {
+			gap = 14
+		}
+		block.add(fonts.Bold, imagePrimary, gap, category.Name)
+		for _, dish := range category.Dishes {
+			block.add(fonts.Body, imagePrimary, 4, dish.Title)
+			if dish.Price != "" {
+				block.add(fonts.Bold, sourceColor(menu.Source), 0, dish.Price)
+			}
+		}
+	}
+	return block
+}
+
+func fillRect(dst draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("error encoding PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// imageSize is the resolution of image output. A zero height means as tall
+// as the content.
+type imageSize struct {
+	Width  int
+	Height int
+}
+
+// outputImageSize is set with -image-size; zero means the format's default.
+var outputImageSize imageSize
+
+// Set parses sizes like "1920x1080" or "1920" (automatic height), so
+// imageSize can be used as a flag.
+func (s *imageSize) Set(value string) error {
+	w, h, hasHeight := strings.Cut(strings.ToLower(value), "x")
+	var size imageSize
+	var err error
+	if size.Width, err = strconv.Atoi(w); err != nil || size.Width < 320 {
+		return fmt.Errorf("invalid image size %q", value)
+	}
+	if hasHeight {
+		if size.Height, err = strconv.Atoi(h); err != nil || size.Height < 240 {
+			return fmt.Errorf("invalid image size %q", value)
+		}
+	}
+	*s = size
+	return nil
+}
+
+func (s *imageSize) String() string {
+	if s == nil || s.Width == 0 {
+		return ""
+	}
+	if s.Height == 0 {
+		return strconv.Itoa(s.Width)
+	}
+	return fmt.Sprintf("%dx%d", s.Width, s.Height)
+}
+
+// orDefault returns s, or def if no size was set.
+func (s imageSize) orDefault(def imageSize) imageSize {
+	if s.Width == 0 {
+		return def
+	}
+	return s
+}
+
+// imageLayout is an image laid out at some font size, ready to be drawn.
+type imageLayout struct {
+	Height int
+	Draw   func(img *image.RGBA)
+}
+
+// fitImage lays out content at decreasing font sizes until it fits size, and
+// encodes it as PNG.
+func fitImage(size imageSize, layout func(scale, fontSize float64) (imageLayout, error)) ([]byte, error) {
+	img, err := drawFitted(size, layout)
+	if err != nil {
+		return nil, err
+	}
+	return encodePNG(img)
+}
+
+// drawFitted lays out content at decreasing font sizes until it fits size,
+// and draws it. Layout dimensions scale with the image width relative to
+// 1920 pixels. With a fixed height, the font may also grow up to double
+// size to fill the screen.
+func drawFitted(size imageSize, layout func(scale, fontSize float64) (imageLayout, error)) (*image.RGBA, error) {
+	scale := float64(size.Width) / 1920
+	start := 17.0
+	if size.Height > 0 {
+		start *= 2
+	}
+	var l imageLayout
+	for fontSize := start; ; fontSize-- {
+		var err error
+		if l, err = layout(scale, fontSize*scale); err != nil {
+			return nil, err
+		}
+		if size.Height == 0 || l.Height <= size.Height || fontSize <= 6 {
+			break
+		}
+	}
+	height := size.Height
+	if height == 0 {
+		height = l.Height
+	}
+	img := image.NewRGBA(image.Rect(0, 0, size.Width, height))
+	fillRect(img, img.Bounds(), imageBackground)
+	l.Draw(img)
+	return img, nil
+}
+
+// renderWeekImage draws the whole week as a grid of weekdays × canteens,
+// sized for sharing in group chats or, with a fixed height, for screens.
+func renderWeekImage(days []DayMenus, title string, size imageSize) ([]byte, error) {
+	return fitImage(size, func(scale, fontSize float64) (imageLayout, error) {
+		var (
+			width    = size.Width
+			margin   = int(40 * scale)
+			gutter   = int(16 * scale)
+			padding  = int(16 * scale)
+			labelCol = int(170 * scale)
+		)
+		fonts, err := loadImageFonts(fontSize)
+		if err != nil {
+			return imageLayout{}, fmt.Errorf("error loading fonts: %w", err)
+		}
+		colWidth := (width - 2*margin - labelCol - len(days)*gutter) / len(days)
+
+		header := &textBlock{Width: width - 2*margin}
+		header.add(fonts.Title, imagePrimary, 0, title)
+		dayHeader := fonts.Heading.Metrics().Height.Ceil()
+
+		// Lay out all cells first; every row is as tall as its tallest cell.
+		rows := days[0].Canteens()
+		cells := make([][]*textBlock, len(rows))
+		rowHeights := make([]int, len(rows))
+		for r := range rows {
+			for _, day := range days {
+				block := menuBlock(fonts, day.Canteens()[r], colWidth-2*padding)
+				cells[r] = append(cells[r], block)
+				rowHeights[r] = max(rowHeights[r], block.height()+2*padding)
+			}
+		}
+
+		height := margin + header.height() + gutter + dayHeader + gutter
+		for _, h := range rowHeights {
+			height += h + gutter
+		}
+		height += margin - gutter
+
+		return imageLayout{Height: height, Draw: func(img *image.RGBA) {
+			header.draw(img, margin, margin)
+
+			y := margin + header.height() + gutter
+			for i, day := range days {
+				x := margin + labelCol + gutter + i*(colWidth+gutter)
+				label := &textBlock{Width: colWidth}
+				label.add(fonts.Heading, imagePrimary, 0, day.Name)
+				label.draw(img, x, y)
+			}
+			y += dayHeader + gutter
+
+			for r, row := range rows {
+				// The label column is narrow, so it shows the short names.
+				label := &textBlock{Width: labelCol}
+				label.add(fonts.Heading, imagePrimary, 0, row.Source.Short)
+				label.draw(img, margin, y+padding)
+				for i, block := range cells[r] {
+					x := margin + labelCol + gutter + i*(colWidth+gutter)
+					fillRect(img, image.Rect(x, y, x+colWidth, y+rowHeights[r]), imageCard)
+					fillRect(img, image.Rect(x, y, x+colWidth, y+max(int(4*scale), 1)), sourceColor(row.Source))
+					block.draw(img, x+padding, y+padding)
+				}
+				y += rowHeights[r] + gutter
+			}
+		}}, nil
+	})
+}
+
+// renderDayImage draws one day with the canteens side by side, or below each
+// other on portrait screens, for screens showing today's menu.
+func renderDayImage(day DayMenus, size imageSize) ([]byte, error) {
+	portrait := size.Height > size.Width
+	return fitImage(size, func(scale, fontSize float64) (imageLayout, error) {
+		var (
+			width   = size.Width
+			margin  = int(40 * scale)
+			gutter  = int(24 * scale)
+			padding = int(24 * scale)
+		)
+		// One column per canteen, so the text can be larger than in the week grid.
+		fonts, err := loadImageFonts(fontSize * 1.6)
+		if err != nil {
+			return imageLayout{}, fmt.Errorf("error loading fonts: %w", err)
+		}
+		canteens := day.Canteens()
+		colWidth := (width - 2*margin - (len(canteens)-1)*gutter) / len(canteens)
+		if portrait {
+			colWidth = width - 2*margin
+		}
+
+		header := &textBlock{Width: width - 2*margin}
+		header.add(fonts.Title, imagePrimary, 0, fmt.Sprintf("%s, %s", day.Name, day.Date.Format("02.01.2006")))
+
+		var blocks []*textBlock
+		cardHeight, stackedHeight := 0, 0
+		for _, menu := range canteens {
+			block := &textBlock{Width: colWidth - 2*padding}
+			block.add(fonts.Heading, imagePrimary, 0, menu.Source.Name)
+			block.Lines = append(block.Lines, menuBlock(fonts, menu, colWidth-2*padding).Lines...)
+			if len(block.Lines) > 1 {
+				block.Lines[1].Gap += gutter
+			}
+			blocks = append(blocks, block)
+			cardHeight = max(cardHeight, block.height()+2*padding)
+			stackedHeight += block.height() + 2*padding + gutter
+		}
+		height := margin + header.height() + gutter + cardHeight + margin
+		if portrait {
+			height = margin + header.height() + stackedHeight + margin
+		}
+
+		return imageLayout{Height: height, Draw: func(img *image.RGBA) {
+			header.draw(img, margin, margin)
+			x, y := margin, margin+header.height()+gutter
+			for i, block := range blocks {
+				h := cardHeight
+				if portrait {
+					h = block.height() + 2*padding
+				}
+				fillRect(img, image.Rect(x, y, x+colWidth, y+h), imageCard)
+				fillRect(img, image.Rect(x, y, x+colWidth, y+max(int(6*scale), 1)), sourceColor(canteens[i].Source))
+				block.draw(img, x+padding, y+padding)
+				if portrait {
+					y += h + gutter
+				} else {
+					x += colWidth + gutter
+				}
+			}
+		}}, nil
+	})
+}
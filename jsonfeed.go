@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// jsonFeed is a JSON Feed 1.1 document, see https://jsonfeed.org/version/1.1.
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	Authors     []jsonFeedAuthor `json:"authors"`
+	Language    string           `json:"language"`
+	Items       []jsonFeedItem   `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html"`
+	DateModified  string `json:"date_modified"`
+	DatePublished string `json:"date_published"`
+}
+
+// renderJSONFeed renders a JSON Feed with the same entries and ids as the
+// Atom feed.
+func renderJSONFeed(days []DayMenus, week WeekMenu) ([]byte, error) {
+	_, items, _, err := buildFeedItems(days, week)
+	if err != nil {
+		return nil, err
+	}
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feedTitle,
+		HomePageURL: siteURL,
+		Authors:     []jsonFeedAuthor{{Name: feedTitle}},
+		Language:    "de",
+		Items:       []jsonFeedItem{},
+	}
+	for _, item := range items {
+		updated := item.Updated.Format(time.RFC3339)
+		feed.Items = append(feed.Items, jsonFeedItem{
+			ID:            item.ID,
+			URL:           siteURL,
+			Title:         item.Title,
+			ContentHTML:   item.HTML,
+			DateModified:  updated,
+			DatePublished: updated,
+		})
+	}
+	data, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling feed: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The types below define the JSON output (-format json). They are part of
+// the tool's interface: fields are only ever added, never renamed or
+// removed. See README.md for a description.
+
+type WeekJSON struct {
+	Year int       `json:"year"`
+	Week int       `json:"week"`
+	Days []DayJSON `json:"days"`
+}
+
+type DayJSON struct {
+	Weekday int          `json:"weekday"` // 1 = Monday … 7 = Sunday
+	Name    string       `json:"name"`
+	Date    string       `json:"date"` // YYYY-MM-DD
+	Closed  bool         `json:"closed,omitempty"`
+	Reason  string       `json:"closed_reason,omitempty"`
+	Sources []SourceJSON `json:"sources"`
+}
+
+type SourceJSON struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Categories  []CategoryJSON `json:"categories"`
+	Annotations []Annotation   `json:"annotations,omitempty"`
+}
+
+type CategoryJSON struct {
+	Name   string     `json:"name"`
+	Dishes []DishJSON `json:"dishes"`
+}
+
+type DishJSON struct {
+	Title      string `json:"title"`
+	Price      string `json:"price"`       // as published by the source, may be empty
+	PriceCents *int   `json:"price_cents"` // null if the price can't be parsed
+}
+
+// renderJSON serializes the merged menus of the week on dates.
+func renderJSON(week WeekMenu, dates []time.Time) ([]byte, error) {
+	out := WeekJSON{Year: week.Year, Week: week.Week, Days: []DayJSON{}}
+	for _, date := range dates {
+		weekday := weekdayOf(date)
+		day := DayJSON{
+			Weekday: int(weekday),
+			Name:    weekday.String(),
+			Date:    date.Format("2006-01-02"),
+			Sources: []SourceJSON{},
+		}
+		day.Reason, day.Closed = closedDates[DateOf(date)]
+		for _, s := range sources {
+			source := SourceJSON{ID: s.ID, Name: sourceView(s, week).Name, Categories: []CategoryJSON{}, Annotations: week.SourceAnnotations(s.ID)}
+			for _, category := range groupByCategory(week.Offerings(DateOf(date), s.ID)) {
+				c := CategoryJSON{Name: category.Name, Dishes: []DishJSON{}}
+				for _, dish := range category.Dishes {
+					d := DishJSON{Title: plainTitle(dish.TitleDe), Price: dish.Price}
+					if cents, ok := parsePrice(dish.Price); ok {
+						d.PriceCents = &cents
+					}
+					c.Dishes = append(c.Dishes, d)
+				}
+				source.Categories = append(source.Categories, c)
+			}
+			day.Sources = append(day.Sources, source)
+		}
+		out.Days = append(out.Days, day)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"krenn.dev/menu/pkg/menu"
+)
+
+// listLocationsCommand implements the list-locations command: it prints
+// the mensen.at locations that can be passed to -location.
+func listLocationsCommand(args []string) error {
+	fs := flag.NewFlagSet("list-locations", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the locations as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	locations, err := menu.MensenAt{Client: httpClient}.Locations(context.Background())
+	if err != nil {
+		return fmt.Errorf("error listing mensen.at locations: %w", err)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].City != locations[j].City {
+			return locations[i].City < locations[j].City
+		}
+		return locations[i].Title < locations[j].Title
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(locations)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LOCATION\tCITY\tTITLE")
+	for _, location := range locations {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", location.URI, location.City, location.Title)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// exitLocked is the exit code used when another instance holds the lock,
+// so cron wrappers can tell a skipped run from a failed one.
+const exitLocked = 3
+
+// errLocked is returned by acquireLock when the lock is already held.
+var errLocked = errors.New("another instance is already running")
+
+// lockOrExit acquires the lock file of a -lock flag, if it is set. If
+// another instance holds the lock, it logs that and exits with exitLocked.
+func lockOrExit(lockFile string) (release func(), err error) {
+	path, err := resolvePath(lockFile)
+	if err != nil || path == "" {
+		return func() {}, err
+	}
+	release, err = acquireLock(path)
+	if errors.Is(err, errLocked) {
+		log.Printf("Skipping run: %v (lock file %s)", err, path)
+		os.Exit(exitLocked)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring lock: %w", err)
+	}
+	return release, nil
+}
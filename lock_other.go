@@ -0,0 +1,27 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// acquireLock creates path exclusively and writes the current pid into it.
+// Unlike flock, a pidfile left behind by a crashed run has to be removed by
+// hand.
+func acquireLock(path string) (release func(), err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, errLocked
+		}
+		return nil, fmt.Errorf("error creating lock file %s: %w", path, err)
+	}
+	fmt.Fprintf(f, "%d\n", os.Getpid())
+	f.Close()
+	return func() {
+		os.Remove(path)
+	}, nil
+}
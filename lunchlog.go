@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// LunchLogEntry is a meal recorded with "log eat".
+type LunchLogEntry struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	Canteen    string `json:"canteen"`
+	Dish       string `json:"dish"`
+	PriceCents int    `json:"price_cents"`
+}
+
+// lunchLogCommand implements the log command and its subcommands.
+func lunchLogCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: log eat|summary [flags]")
+	}
+	switch args[0] {
+	case "eat":
+		return lunchLogEat(args[1:])
+	case "summary":
+		return lunchLogSummary(args[1:])
+	default:
+		return fmt.Errorf("unknown log command %q (want eat or summary)", args[0])
+	}
+}
+
+func lunchLogEat(args []string) error {
+	fs := flag.NewFlagSet("log eat", flag.ExitOnError)
+	canteen := fs.String("canteen", "", "Canteen: "+strings.Join(sourceIDs(), ", "))
+	dish := fs.String("dish", "", "Dish, or part of its title to look it up in the day's menu")
+	price := fs.String("price", "", "Price paid (default: the menu price)")
+	date := fs.String("date", time.Now().Format("2006-01-02"), "Date of the meal")
+	fs.BoolVar(&portable, "portable", false, "Keep the log next to the executable")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var source *menuSource
+	for i := range sources {
+		if sources[i].ID == *canteen {
+			source = &sources[i]
+		}
+	}
+	if source == nil {
+		return fmt.Errorf("unknown canteen %q", *canteen)
+	}
+	if *dish == "" {
+		return errors.New("missing -dish")
+	}
+	day, err := time.ParseInLocation("2006-01-02", *date, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %w", *date, err)
+	}
+
+	entry := LunchLogEntry{Date: *date, Canteen: source.ID, Dish: *dish}
+	if match, ok := findDish(*source, day, *dish); ok {
+		entry.Dish = plainTitle(match.TitleDe)
+		entry.PriceCents, _ = parsePrice(match.Price)
+	}
+	if *price != "" {
+		cents, ok := parsePrice(*price)
+		if !ok {
+			return fmt.Errorf("invalid price %q", *price)
+		}
+		entry.PriceCents = cents
+	}
+	if entry.PriceCents == 0 {
+		return fmt.Errorf("no price for %q in the %s menu of %s, pass -price", *dish, source.Name, *date)
+	}
+
+	path, err := lunchLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating data directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening lunch log: %w", err)
+	}
+	defer f.Close()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing lunch log: %w", err)
+	}
+	fmt.Printf("Logged %s at %s for %s %s\n", entry.Dish, source.Name, priceFormat.Symbol, formatAmount(entry.PriceCents))
+	return nil
+}
+
+// findDish looks up the first dish of the given day whose title contains
+// query.
+func findDish(source menuSource, day time.Time, query string) (Dish, bool) {
+	plan, weekday, ok := planForDay(source, day)
+	if !ok {
+		return Dish{}, false
+	}
+	query = strings.ToLower(query)
+	for _, category := range plan.Menus {
+		for _, dish := range category.Menus[weekday] {
+			if strings.Contains(strings.ToLower(plainTitle(dish.TitleDe)), query) {
+				return dish, true
+			}
+		}
+	}
+	return Dish{}, false
+}
+
+// readLunchLog returns all logged meals in the order they were recorded.
+func readLunchLog() ([]LunchLogEntry, error) {
+	path, err := lunchLogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening lunch log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []LunchLogEntry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry LunchLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// monthlySpend aggregates logged meals per month.
+type monthlySpend struct {
+	Month      string // YYYY-MM
+	Meals      int
+	TotalCents int
+}
+
+func (m monthlySpend) AverageCents() int {
+	if m.Meals == 0 {
+		return 0
+	}
+	return m.TotalCents / m.Meals
+}
+
+// summarizeLunchLog returns spending per month, oldest first.
+func summarizeLunchLog(entries []LunchLogEntry) []monthlySpend {
+	byMonth := make(map[string]*monthlySpend)
+	for _, entry := range entries {
+		if len(entry.Date) < 7 {
+			continue
+		}
+		month := entry.Date[:7]
+		m, ok := byMonth[month]
+		if !ok {
+			m = &monthlySpend{Month: month}
+			byMonth[month] = m
+		}
+		m.Meals++
+		m.TotalCents += entry.PriceCents
+	}
+	months := make([]monthlySpend, 0, len(byMonth))
+	for _, m := range byMonth {
+		months = append(months, *m)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Month < months[j].Month })
+	return months
+}
+
+// projectedCents extrapolates a month's spend to the whole month. Months
+// that are over are returned as they are.
+func (m monthlySpend) projectedCents(now time.Time) int {
+	if m.Month != now.Format("2006-01") {
+		return m.TotalCents
+	}
+	daysInMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	return m.TotalCents * daysInMonth / now.Day()
+}
+
+func lunchLogSummary(args []string) error {
+	fs := flag.NewFlagSet("log summary", flag.ExitOnError)
+	month := fs.String("month", "", "Only show this month (YYYY-MM)")
+	budget := fs.String("budget", "", "Monthly lunch budget, e.g. 120")
+	csvFile := fs.String("csv", "", "Write the summary as CSV to this file (- for stdout)")
+	fs.BoolVar(&portable, "portable", false, "Resolve relative paths against the executable's directory instead of the working directory, and read the log from there")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	budgetCents := 0
+	if *budget != "" {
+		cents, ok := parsePrice(*budget)
+		if !ok {
+			return fmt.Errorf("invalid budget %q", *budget)
+		}
+		budgetCents = cents
+	}
+
+	entries, err := readLunchLog()
+	if err != nil {
+		return err
+	}
+
+	// The table uses decimal commas like the menu; CSV uses plain decimal
+	// points so spreadsheets and scripts can read it regardless of locale.
+	amount := formatSignedAmount
+	if *csvFile != "" {
+		amount = func(cents int) string { return strconv.FormatFloat(float64(cents)/100, 'f', 2, 64) }
+	}
+	now := time.Now()
+	header := []string{"Month", "Meals", "Total", "Average", "Projected"}
+	if budgetCents > 0 {
+		header = append(header, "Budget", "Remaining")
+	}
+	rows := [][]string{header}
+	for _, m := range summarizeLunchLog(entries) {
+		if *month != "" && m.Month != *month {
+			continue
+		}
+		projected := m.projectedCents(now)
+		row := []string{m.Month, strconv.Itoa(m.Meals), amount(m.TotalCents), amount(m.AverageCents()), amount(projected)}
+		if budgetCents > 0 {
+			row = append(row, amount(budgetCents), amount(budgetCents-projected))
+		}
+		rows = append(rows, row)
+	}
+
+	if *csvFile != "" {
+		out := os.Stdout
+		if *csvFile != "-" {
+			csvPath, err := resolvePath(*csvFile)
+			if err != nil {
+				return err
+			}
+			f, err := os.Create(csvPath)
+			if err != nil {
+				return fmt.Errorf("error creating CSV file: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		w := csv.NewWriter(out)
+		w.WriteAll(rows)
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+	}
+	return w.Flush()
+}
+
+// formatSignedAmount is formatAmount for amounts that may be negative.
+func formatSignedAmount(cents int) string {
+	if cents < 0 {
+		return "-" + formatAmount(-cents)
+	}
+	return formatAmount(cents)
+}
+
+func lunchLogPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lunch-log.jsonl"), nil
+}
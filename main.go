@@ -1,139 +1,397 @@
 package main
 
 import (
-	"bytes" // Still needed to escape non-description fields
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"html"
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	_ "embed"
+
+	"krenn.dev/menu/pkg/menu"
 )
 
 //go:embed menu_for_week_tabs.tmpl
 var menuForWeekTabsTemplate string
 
-type GraphQLRequest struct {
-	Query         string    `json:"query"`
-	Variables     Variables `json:"variables"`
-	OperationName string    `json:"operationName"`
+// The data model lives in pkg/menu, so other programs can fetch menus
+// without this command. The aliases keep the names used by the renderers.
+type (
+	MenuPlan     = menu.Plan
+	MenuCategory = menu.Category
+	Dish         = menu.Dish
+	Weekday      = menu.Weekday
+	Annotation   = menu.Annotation
+)
+
+const (
+	Monday    = menu.Monday
+	Tuesday   = menu.Tuesday
+	Wednesday = menu.Wednesday
+	Thursday  = menu.Thursday
+	Friday    = menu.Friday
+	Saturday  = menu.Saturday
+	Sunday    = menu.Sunday
+)
+
+// weekdayOf returns the Weekday of t.
+func weekdayOf(t time.Time) Weekday {
+	return menu.WeekdayOf(t)
 }
 
-type Variables struct {
-	LocationURI string `json:"locationUri"`
-	WeekDay     string `json:"weekDay"`
+// commands are the subcommands; without one the menus are fetched and
+// rendered in one go.
+var commands = map[string]func(args []string) error{
+	"dump":            dumpCommand,
+	"fetch":           fetchCommand,
+	"install-service": installService,
+	"list-locations":  listLocationsCommand,
+	"log":             lunchLogCommand,
+	"probe":           probeCommand,
+	"rank":            rankCommand,
+	"render":          renderCommand,
+	"serve":           serveCommand,
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	outputFile := flag.String("o", "", outputUsage)
+	formatList := flag.String("format", "html", formatUsage())
+	lockFile := flag.String("lock", "", "Lock file guarding against overlapping runs (default: no locking)")
+	reportFile := flag.String("report", "", "Write a JSON run report to this file")
+	statusFile := flag.String("status", "", "Write a public status page summarizing source health to this file")
+	fetchOpts := addFetchFlags(flag.CommandLine)
+	opts := addRenderFlags(flag.CommandLine)
+	if err := parseFlags(flag.CommandLine, os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+
+	formats, err := parseFormats(*formatList)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := opts.apply(); err != nil {
+		log.Fatal(err)
+	}
+	if err := fetchOpts.apply(); err != nil {
+		log.Fatal(err)
+	}
+
+	lockPath, err := resolvePath(*lockFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reportPath, err := resolvePath(*reportFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	report := &RunReport{Started: time.Now(), Sources: []SourceReport{}, Outputs: []string{}}
+	finishReport := func(runErr error) {
+		if reportPath == "" {
+			return
+		}
+		if err := writeReport(reportPath, report, runErr); err != nil {
+			log.Print(err)
+		}
+	}
+
+	release := func() {}
+	if lockPath != "" {
+		release, err = acquireLock(lockPath)
+		if errors.Is(err, errLocked) {
+			log.Printf("Skipping run: %v (lock file %s)", err, lockPath)
+			report.Status = "skipped"
+			finishReport(err)
+			os.Exit(exitLocked)
+		}
+		if err != nil {
+			log.Fatalf("Error acquiring lock: %v", err)
+		}
+	}
+
+	err = run(*outputFile, formats, opts.day, report)
+	if *statusFile != "" {
+		if statusPath, pathErr := resolvePath(*statusFile); pathErr != nil {
+			log.Print(pathErr)
+		} else if statusErr := writeStatusPage(statusPath, report); statusErr != nil {
+			log.Print(statusErr)
+		} else {
+			report.Outputs = append(report.Outputs, statusPath)
+		}
+	}
+	release()
+	finishReport(err)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-// APIResponse matches the outer JSON structure
-type APIResponse struct {
-	Data struct {
-		NodeByUri struct {
-			Title               string `json:"title"`
-			MenuplanCurrentWeek string `json:"menuplanCurrentWeek"` // This is stringified JSON
-		} `json:"nodeByUri"`
-	} `json:"data"`
+// run fetches all menus and writes the week, or only day if set, rendered
+// in each of formats to outputFile, recording what happened in report. See
+// writeFormats for the output files.
+func run(outputFile string, formats []outputFormat, day string, report *RunReport) error {
+	plans, next := fetchWeeks(report, needsNextWeek(formats, day))
+	return writeFormats(outputFile, formats, day, plans, next, report)
 }
 
-// MenuPlan matches the inner, stringified JSON structure
-type MenuPlan struct {
-	Week  string         `json:"week"`
-	Year  int            `json:"year"`
-	Menus []MenuCategory `json:"menus"`
+// fetchOptions are the flags shared by the commands that fetch menus.
+type fetchOptions struct {
+	sources        *sourceOptions
+	schemaBaseline string
 }
 
-type MenuCategory struct {
-	Name  string            `json:"name"`
-	Menus map[string][]Dish `json:"menus"` // Key is the day of the week ("1", "2", etc.)
+// addFetchFlags registers the fetching flags on fs.
+func addFetchFlags(fs *flag.FlagSet) *fetchOptions {
+	opts := &fetchOptions{sources: addSourceFlags(fs)}
+	fs.StringVar(&opts.schemaBaseline, "schema-baseline", "default", "File name of the baselines remembering the mensen.at payload keys per location and week to detect schema drift (\"default\": in the user cache directory, empty: disabled)")
+	fs.DurationVar(&httpClient.Timeout, "timeout", httpClient.Timeout, "Timeout of each request to a source")
+	return opts
 }
 
-type Dish struct {
-	TitleDe string `json:"title_de"`
-	Price   string `json:"price"`
+// apply applies the parsed flags.
+func (opts *fetchOptions) apply() error {
+	if err := opts.sources.apply(); err != nil {
+		return err
+	}
+	return setSchemaBaseline(opts.schemaBaseline)
 }
 
-func main() {
-	outputFile := flag.String("o", "index.html", "Output filename (default: index.html)")
-	flag.Parse()
+// fetchPlans fetches the plans of all sources concurrently, sharing
+// httpClient. Plans and their reports are in the order of sources.
+func fetchPlans(report *RunReport) []MenuPlan {
+	plans := make([]MenuPlan, len(sources))
+	reports := make([]SourceReport, len(sources))
+	var wg sync.WaitGroup
+	for i, s := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			plans[i], reports[i] = fetchSource(s)
+		}()
+	}
+	wg.Wait()
+	report.Sources = append(report.Sources, reports...)
+	return plans
+}
+
+// fetchWeeks fetches the plans of all sources like fetchPlans, and next
+// week's plans alongside if withNext is set, each source in parallel.
+// Otherwise next week is only fetched, afterwards, if this week has no
+// dishes left, for the preview every format shows then; next is nil if it
+// isn't fetched.
+func fetchWeeks(report *RunReport, withNext bool) (plans, next []MenuPlan) {
+	if !withNext {
+		plans = fetchPlans(report)
+		vienna, err := time.LoadLocation("Europe/Vienna")
+		if err == nil && buildWeekMenu(plans...).hasDishesFrom(DateOf(time.Now().In(vienna))) {
+			return plans, nil
+		}
+		return plans, fetchNextWeeks()
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		next = fetchNextWeeks()
+	}()
+	plans = fetchPlans(report)
+	wg.Wait()
+	return plans, next
+}
 
-	jkuMensa, err := fetchJKUMensa()
+// fetchNextWeeks fetches next week's plans of all sources in parallel.
+func fetchNextWeeks() []MenuPlan {
+	var wg sync.WaitGroup
+	next := make([]MenuPlan, len(sources))
+	for i, s := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			next[i] = fetchNextWeek(s)
+		}()
+	}
+	wg.Wait()
+	return next
+}
+
+// fetchNextWeek fetches next week's plan of a source. Plans that aren't
+// published yet are common, so there are no retries and no cached
+// fallback; a missing plan is only noted on the plan returned.
+func fetchNextWeek(s menuSource) MenuPlan {
+	source, ok := s.Source.(menu.NextWeekSource)
+	if !ok {
+		var plan MenuPlan
+		plan.Annotate(menu.LevelInfo, "Next week's menu isn't published in advance")
+		return plan
+	}
+	plan, err := source.FetchNextWeek(context.Background())
 	if err != nil {
-		log.Printf("Error fetching JKU menu: %v", err)
+		if !errors.Is(err, menu.ErrEmpty) {
+			log.Printf("Error fetching next week's %s menu: %v", s.Name, err)
+		}
+		plan = MenuPlan{}
+		plan.Annotate(menu.LevelInfo, "Next week's menu isn't published yet")
+		return plan
 	}
-	khgMenu, err := fetchKHGMenu()
+	plan.Location = s.Location
+	if err := saveCachedPlan(nextWeekCacheID(s.ID), plan); err != nil {
+		log.Printf("Error caching next week's %s menu: %v", s.Name, err)
+	}
+	return plan
+}
+
+// fetchCommand implements the fetch command: it fetches all sources into the
+// cache, for render to pick up, and prints a line per source.
+func fetchCommand(args []string) error {
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	opts := addFetchFlags(fs)
+	lockFile := fs.String("lock", "", "Lock file guarding against overlapping runs (default: no locking)")
+	fs.BoolVar(&portable, "portable", false, "Resolve relative paths against the executable's directory instead of the working directory")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := opts.apply(); err != nil {
+		return err
+	}
+	release, err := lockOrExit(*lockFile)
 	if err != nil {
-		log.Printf("Error fetching KHG menu: %v", err)
+		return err
 	}
+	defer release()
 
-	htmlOutput := renderMenusForWeekTabs(jkuMensa, khgMenu)
-	if err := os.WriteFile(*outputFile, []byte(htmlOutput), 0644); err != nil {
-		log.Fatalf("Error writing week tabs HTML to file: %v", err)
+	// Fetch both weeks for render, whatever it is asked for.
+	report := &RunReport{}
+	fetchWeeks(report, true)
+	var failed []string
+	for _, source := range report.Sources {
+		fmt.Printf("%s: %s, week %s/%d, %d dishes\n", source.Name, source.Status, source.Week, source.Year, source.Dishes)
+		if source.Status == "error" {
+			failed = append(failed, source.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to fetch %s", strings.Join(failed, ", "))
 	}
+	return nil
 }
 
-func renderMenusForWeekTabs(jkuMensa MenuPlan, khgMenu MenuPlan) string {
-	type DishView struct {
-		Title string
-		Price string
-	}
-	type CategoryView struct {
-		Name   string
-		Dishes []DishView
-	}
-	type MenuView struct {
-		Categories []CategoryView
-	}
-	type DayMenus struct {
-		Name     string
-		JKUMensa MenuView
-		KHG      MenuView
-	}
-	dayNames := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
-	var days []DayMenus
-	for i, dayName := range dayNames {
-		dayKey := fmt.Sprintf("%d", i+1)
-		getMenuView := func(menu MenuPlan) MenuView {
-			var categories []CategoryView
-			for _, category := range menu.Menus {
-				dishes, dayExists := category.Menus[dayKey]
-				if dayExists && len(dishes) > 0 {
-					var dishViews []DishView
-					for _, dish := range dishes {
-						dishViews = append(dishViews, DishView{
-							Title: formatTitleForHTML(dish.TitleDe),
-							Price: html.EscapeString(dish.Price),
-						})
-					}
-					categories = append(categories, CategoryView{
-						Name:   html.EscapeString(category.Name),
-						Dishes: dishViews,
-					})
-				}
+const (
+	fetchRetries    = 2
+	fetchRetryDelay = 5 * time.Second
+)
+
+// fetchSource fetches a source and decides what to do about failures:
+// unavailable upstreams are retried, unusable responses fall back to the
+// last good plan, and stale or empty plans are used but flagged. The outcome
+// is returned along with the plan.
+func fetchSource(s menuSource) (MenuPlan, SourceReport) {
+	start := time.Now()
+	var plan MenuPlan
+	var err error
+	attempts := 0
+	for {
+		attempts++
+		plan, err = s.Source.Fetch(context.Background())
+		if !errors.Is(err, menu.ErrUpstreamUnavailable) || attempts > fetchRetries {
+			break
+		}
+		log.Printf("%s unavailable, retrying: %v", s.Name, err)
+		time.Sleep(time.Duration(attempts) * fetchRetryDelay)
+	}
+
+	source := SourceReport{Name: s.Name, Status: "ok", Attempts: attempts}
+	switch {
+	case err == nil:
+		if err := saveCachedPlan(s.ID, plan); err != nil {
+			log.Printf("Error caching %s menu: %v", s.Name, err)
+		}
+	case errors.Is(err, menu.ErrStale), errors.Is(err, menu.ErrEmpty):
+		log.Printf("WARNING: %s menu: %v", s.Name, err)
+		source.Status = "warning"
+		source.Error = err.Error()
+		if errors.Is(err, menu.ErrStale) {
+			plan.Annotate(menu.LevelWarning, "The menu is for a past week")
+		} else {
+			plan.Annotate(menu.LevelWarning, "No dishes were published")
+		}
+	default:
+		log.Printf("Error fetching %s menu: %v", s.Name, err)
+		source.Status = "error"
+		source.Error = err.Error()
+		if errors.Is(err, menu.ErrUpstreamUnavailable) || errors.Is(err, menu.ErrParse) {
+			cached, cacheErr := loadCachedPlan(s.ID)
+			if cacheErr == nil && cached.Location == s.Location && !errors.Is(menu.Check(cached), menu.ErrStale) {
+				log.Printf("Using cached %s menu for week %s", s.Name, cached.Week)
+				plan = cached
+				source.Status = "cached"
+				plan.Annotate(menu.LevelWarning, "The live menu couldn't be fetched, showing the last fetched one")
+				break
 			}
-			return MenuView{Categories: categories}
 		}
-		days = append(days, DayMenus{
-			Name:     dayName,
-			JKUMensa: getMenuView(jkuMensa),
-			KHG:      getMenuView(khgMenu),
-		})
+		plan.Annotate(menu.LevelWarning, "The menu couldn't be fetched")
 	}
-	data := map[string]interface{}{
-		"Days": days,
+	source.DurationMS = time.Since(start).Milliseconds()
+	source.Week = plan.Week
+	source.Year = plan.Year
+	source.Dishes = plan.DishCount()
+	return plan, source
+}
+
+// expandOutputPath executes the output filename as a template, so archives
+// can be organized by week, e.g. "archive/menu-{{.Year}}-KW{{.Week}}.html".
+// Week and year are taken from the first menu that has them, falling back
+// to the current ISO week.
+func expandOutputPath(pattern string, format outputFormat, menus ...MenuPlan) (string, error) {
+	var data struct {
+		Year   int
+		Week   int
+		Format string
+		Ext    string
 	}
-	tmpl, err := template.New("menu_for_week_tabs").Parse(menuForWeekTabsTemplate)
+	data.Year, data.Week = displayWeek(menus...).ISOWeek()
+	data.Format, data.Ext = format.Name, format.Extension
+
+	tmpl, err := template.New("output").Parse(pattern)
 	if err != nil {
-		return "<h2>Template error.</h2>"
+		return "", err
 	}
 	var buf bytes.Buffer
-	tmpl.Execute(&buf, data)
-	return buf.String()
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
 
-func formatTitleForHTML(title string) string {
-	r := strings.NewReplacer("\n", " ")
-	cleaned := r.Replace(title)
-	return strings.TrimSpace(cleaned)
+func renderMenusForWeekTabs(days []DayMenus) ([]byte, error) {
+	data := map[string]interface{}{
+		"Days": days,
+	}
+	limits := new(templateLimits)
+	tmpl, err := template.New("menu_for_week_tabs").Funcs(limits.funcs()).Parse(menuForWeekTabsTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing tabs template: %w", err)
+	}
+	if err := parseCustomTemplate(tmpl, "menu_for_week_tabs.tmpl", limits); err != nil {
+		return nil, err
+	}
+	output, err := executeTemplate(tmpl, data, limits)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering tabs page: %w", err)
+	}
+	return output, nil
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderMarkdown renders the week as Markdown for wikis and chat: a heading
+// per day, a subheading per canteen and a table of its dishes.
+func renderMarkdown(days []DayMenus, title string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", title)
+	for _, day := range days {
+		fmt.Fprintf(&b, "\n## %s\n", day.Name)
+		for _, menu := range day.Canteens() {
+			fmt.Fprintf(&b, "\n### %s\n\n", menu.Source.Name)
+			for _, annotation := range menu.Annotations {
+				fmt.Fprintf(&b, "> %s\n\n", markdownCell(annotation.String()))
+			}
+			if menu.Closed != "" {
+				fmt.Fprintf(&b, "_%s_\n", markdownCell(menu.Closed))
+				continue
+			}
+			if len(menu.Categories) == 0 {
+				fmt.Fprintf(&b, "_No menu data found for %s._\n", day.Name)
+				continue
+			}
+			b.WriteString("| Category | Dish | Price |\n")
+			b.WriteString("| --- | --- | ---: |\n")
+			for _, category := range menu.Categories {
+				for _, dish := range category.Dishes {
+					fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(category.Name), markdownCell(dish.Title), markdownCell(dish.Price))
+				}
+			}
+		}
+	}
+	if asciiOutput {
+		return []byte(toASCII(b.String()))
+	}
+	return []byte(b.String())
+}
+
+// markdownCell escapes text for use in a table cell.
+func markdownCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+)
+
+//go:embed narrow.tmpl
+var narrowTemplate string
+
+// renderNarrow renders the week for phones: the days stacked in a single
+// column with a scrolling day bar, short source names and no tabs, so
+// nothing depends on the screen being wide.
+func renderNarrow(days []DayMenus, title string) ([]byte, error) {
+	data := struct {
+		Title string
+		Days  []DayMenus
+	}{Title: title, Days: days}
+
+	limits := new(templateLimits)
+	tmpl, err := template.New("narrow").Funcs(limits.funcs()).Parse(narrowTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing narrow template: %w", err)
+	}
+	if err := parseCustomTemplate(tmpl, "narrow.tmpl", limits); err != nil {
+		return nil, err
+	}
+	output, err := executeTemplate(tmpl, data, limits)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering narrow page: %w", err)
+	}
+	return output, nil
+}
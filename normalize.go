@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"krenn.dev/menu/pkg/menu"
+)
+
+// Date is a calendar day without time or location, so menus can be looked
+// up by date independent of time zones and DST.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of t in t's location.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Year: year, Month: month, Day: day}
+}
+
+// In returns midnight of the date in loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// AddDays returns the date n days after d.
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.In(time.UTC).AddDate(0, 0, n))
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// Offering is a dish served by a source on some date.
+type Offering struct {
+	SourceID string
+	Category string
+	Dish     Dish
+}
+
+// WeekMenu is the normalized form of the fetched plans that the renderers
+// work on: the offerings of all sources indexed by date, with upstream
+// quirks such as per-source week numbering and padded category names
+// already resolved.
+type WeekMenu struct {
+	Year        int
+	Week        int
+	Days        map[Date][]Offering
+	Annotations []Annotation
+	Titles      map[string]string // names published by the sources, by source ID
+}
+
+// How duplicates within a source's menu of one day are handled: categories
+// with the same name, and the same dish listed twice in a category.
+const (
+	// mergeKeepFirst drops later categories of the same name and repeated
+	// dishes.
+	mergeKeepFirst = "keep-first"
+	// mergeCategories combines categories of the same name into one and
+	// drops repeated dishes.
+	mergeCategories = "merge"
+	// mergeSuffix keeps everything, numbering later categories of the same
+	// name, e.g. "Menü 1 (2)".
+	mergeSuffix = "suffix"
+)
+
+var mergeStrategies = []string{mergeKeepFirst, mergeCategories, mergeSuffix}
+
+// mergeStrategy is the strategy applied by buildWeekMenu.
+var mergeStrategy = mergeCategories
+
+// buildWeekMenu normalizes plans, which are given in the order of sources.
+// The week shown is that of the first plan which has one, or the current
+// week.
+func buildWeekMenu(plans ...MenuPlan) WeekMenu {
+	year, week := displayWeek(plans...).ISOWeek()
+	weekMenu := WeekMenu{Year: year, Week: week, Days: make(map[Date][]Offering), Titles: make(map[string]string)}
+	for i, plan := range plans {
+		weekMenu.add(sources[i].ID, plan)
+		if plan.Title != "" {
+			weekMenu.Titles[sources[i].ID] = plan.Title
+		}
+		for _, annotation := range plan.Annotations {
+			annotation.Source = sources[i].ID
+			weekMenu.Annotations = append(weekMenu.Annotations, annotation)
+		}
+		if start, ok := plan.WeekStart(); ok && DateOf(start) != DateOf(weekMenu.Monday()) {
+			planYear, planWeek := start.ISOWeek()
+			weekMenu.Annotations = append(weekMenu.Annotations, Annotation{
+				Level:   menu.LevelWarning,
+				Source:  sources[i].ID,
+				Message: fmt.Sprintf("The menu is for week %d/%d, not the displayed week", planWeek, planYear),
+			})
+		}
+	}
+	for date := range closedDates {
+		delete(weekMenu.Days, date)
+	}
+	return weekMenu
+}
+
+// add records the offerings of plan. Dishes are placed on the dates of the
+// plan's own week, or of the displayed week if the plan has none.
+func (w WeekMenu) add(sourceID string, plan MenuPlan) {
+	start, ok := plan.WeekStart()
+	if !ok {
+		start = w.Monday()
+	}
+	monday := DateOf(start)
+	for day := Monday; day <= Sunday; day++ {
+		date := monday.AddDays(int(day) - 1)
+		w.Days[date] = append(w.Days[date], dayOfferings(sourceID, plan, day, mergeStrategy)...)
+	}
+}
+
+// dayOfferings returns what plan offers on day, with duplicates handled
+// according to strategy.
+func dayOfferings(sourceID string, plan MenuPlan, day Weekday, strategy string) []Offering {
+	var offerings []Offering
+	occurrences := make(map[string]int)
+	seen := make(map[string]bool) // category and dish
+	for _, category := range plan.Menus {
+		dishes := category.Menus[day]
+		if len(dishes) == 0 {
+			continue
+		}
+		name := strings.TrimSpace(category.Name)
+		occurrences[name]++
+		if n := occurrences[name]; n > 1 {
+			switch strategy {
+			case mergeKeepFirst:
+				continue
+			case mergeSuffix:
+				name = fmt.Sprintf("%s (%d)", name, n)
+			}
+		}
+		for _, dish := range dishes {
+			key := name + "\x00" + strings.ToLower(plainTitle(dish.TitleDe))
+			if seen[key] && strategy != mergeSuffix {
+				continue
+			}
+			seen[key] = true
+			offerings = append(offerings, Offering{SourceID: sourceID, Category: name, Dish: dish})
+		}
+	}
+	return offerings
+}
+
+// Monday returns the first day of the menu's week.
+func (w WeekMenu) Monday() time.Time {
+	return menu.ISOWeekStart(w.Year, w.Week, time.Local)
+}
+
+// Offerings returns what sourceID offers on date, in the source's order.
+func (w WeekMenu) Offerings(date Date, sourceID string) []Offering {
+	var offerings []Offering
+	for _, offering := range w.Days[date] {
+		if offering.SourceID == sourceID {
+			offerings = append(offerings, offering)
+		}
+	}
+	return offerings
+}
+
+// SourceAnnotations returns the annotations concerning sourceID.
+func (w WeekMenu) SourceAnnotations(sourceID string) []Annotation {
+	var annotations []Annotation
+	for _, annotation := range w.Annotations {
+		if annotation.Source == sourceID {
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations
+}
+
+// hasDishesFrom reports whether any displayed day from today on offers
+// dishes.
+func (w WeekMenu) hasDishesFrom(today Date) bool {
+	for _, date := range displayedDates(w.Monday()) {
+		if !DateOf(date).In(time.UTC).Before(today.In(time.UTC)) && len(w.Days[DateOf(date)]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateSources adds an annotation concerning every shown source.
+func (w *WeekMenu) annotateSources(level, message string) {
+	for _, s := range sources {
+		w.Annotations = append(w.Annotations, Annotation{Level: level, Source: s.ID, Message: message})
+	}
+}
+
+// CategoryOfferings are the dishes of one category.
+type CategoryOfferings struct {
+	Name   string
+	Dishes []Dish
+}
+
+// groupByCategory groups offerings by category, keeping the order in which
+// categories first appear.
+func groupByCategory(offerings []Offering) []CategoryOfferings {
+	var categories []CategoryOfferings
+	index := make(map[string]int)
+	for _, offering := range offerings {
+		i, ok := index[offering.Category]
+		if !ok {
+			i = len(categories)
+			index[offering.Category] = i
+			categories = append(categories, CategoryOfferings{Name: offering.Category})
+		}
+		categories[i].Dishes = append(categories[i].Dishes, offering.Dish)
+	}
+	return categories
+}
+
+// Title returns the heading used by the non-HTML renderers.
+func (w WeekMenu) Title() string {
+	return fmt.Sprintf("Menu KW %d / %d", w.Week, w.Year)
+}
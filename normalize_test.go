@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"krenn.dev/menu/pkg/menu"
+)
+
+// samplePlans returns the plans of the recorded responses in samplereqresp,
+// by source ID.
+func samplePlans(t *testing.T) map[string]MenuPlan {
+	t.Helper()
+	data, err := os.ReadFile("samplereqresp/jku.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The file is the curl command followed by the response.
+	var response struct {
+		Data struct {
+			NodeByUri struct {
+				Menuplan string `json:"menuplanCurrentWeek"`
+			} `json:"nodeByUri"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data[bytes.Index(data, []byte("\n{"))+1:], &response); err != nil {
+		t.Fatal(err)
+	}
+	jku, _, err := menu.DecodePlan([]byte(response.Data.NodeByUri.Menuplan))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open("samplereqresp/khg.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	khg, err := menu.ParseKHG(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]MenuPlan{"jku": jku, "khg": khg}
+}
+
+// offeringLines returns "category: dish" for each offering.
+func offeringLines(offerings []Offering) []string {
+	lines := make([]string, len(offerings))
+	for i, o := range offerings {
+		lines[i] = o.Category + ": " + plainTitle(o.Dish.TitleDe)
+	}
+	return lines
+}
+
+func TestDayOfferings(t *testing.T) {
+	plans := samplePlans(t)
+
+	// The samples have no duplicates, so every strategy returns all dishes.
+	for id, plan := range plans {
+		for day := Monday; day <= Friday; day++ {
+			var want []string
+			for _, category := range plan.Menus {
+				for _, dish := range category.Menus[day] {
+					want = append(want, strings.TrimSpace(category.Name)+": "+plainTitle(dish.TitleDe))
+				}
+			}
+			for _, strategy := range mergeStrategies {
+				got := offeringLines(dayOfferings(id, plan, day, strategy))
+				if !slices.Equal(got, want) {
+					t.Errorf("%s %v %s:\ngot  %q\nwant %q", id, day, strategy, got, want)
+				}
+			}
+		}
+	}
+
+	// A category listed twice on Monday, padded, with one dish of the first
+	// listing and a new one listed twice.
+	plan := plans["jku"]
+	first := plan.Menus[0]
+	plan.Menus = append(slices.Clone(plan.Menus), MenuCategory{
+		Name: first.Name + " ",
+		Menus: map[Weekday][]Dish{
+			Monday: {first.Menus[Monday][0], {TitleDe: "Gemüsecurry mit Reis"}, {TitleDe: "Gemüsecurry mit reis"}},
+		},
+	})
+	original := offeringLines(dayOfferings("jku", plans["jku"], Monday, mergeKeepFirst))
+	tests := []struct {
+		strategy string
+		added    []string
+	}{
+		{mergeKeepFirst, nil},
+		{mergeCategories, []string{
+			"Menü veggie/vegan (mit Suppe, Salat und Getränk): Gemüsecurry mit Reis",
+		}},
+		{mergeSuffix, []string{
+			"Menü veggie/vegan (mit Suppe, Salat und Getränk) (2): Asiatischer Egg-Fried Rice mit frischem Kräutertopping",
+			"Menü veggie/vegan (mit Suppe, Salat und Getränk) (2): Gemüsecurry mit Reis",
+			"Menü veggie/vegan (mit Suppe, Salat und Getränk) (2): Gemüsecurry mit reis",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			got := offeringLines(dayOfferings("jku", plan, Monday, tt.strategy))
+			want := append(slices.Clone(original), tt.added...)
+			if !slices.Equal(got, want) {
+				t.Errorf("got  %q\nwant %q", got, want)
+			}
+			// Days without duplicates are unaffected.
+			got = offeringLines(dayOfferings("jku", plan, Tuesday, tt.strategy))
+			want = offeringLines(dayOfferings("jku", plans["jku"], Tuesday, tt.strategy))
+			if !slices.Equal(got, want) {
+				t.Errorf("Tuesday: got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// portable makes relative paths resolve against the directory of the
+// executable instead of the working directory. Windows Task Scheduler starts
+// programs in C:\Windows\System32 unless told otherwise, so without this a
+// relative output path lands in the wrong place.
+var portable bool
+
+// resolvePath returns path unchanged unless portable mode is on and the path
+// is relative, in which case it is anchored at the executable's directory.
+func resolvePath(path string) (string, error) {
+	if !portable || path == "" || filepath.IsAbs(path) {
+		return path, nil
+	}
+	dir, err := executableDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path), nil
+}
+
+// executableDir returns the directory containing the running binary, with
+// symlinks resolved so a linked binary still finds its own files.
+func executableDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("error locating executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Dir(exe), nil
+}
+
+// stateDir returns the directory for files the tool keeps between runs. In
+// portable mode that is the executable's directory, otherwise the
+// OS-specific user cache directory.
+func stateDir() (string, error) {
+	if portable {
+		return executableDir()
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "jku-menu"), nil
+}
+
+// dataDir returns the directory for user data that must not be purged like
+// a cache, such as the lunch log. In portable mode that is the executable's
+// directory, otherwise a directory below the OS-specific user config
+// directory.
+func dataDir() (string, error) {
+	if portable {
+		return executableDir()
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating user config directory: %w", err)
+	}
+	return filepath.Join(dir, "jku-menu"), nil
+}
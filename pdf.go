@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// pdfLine is a paragraph of a PDF table cell.
+type pdfLine struct {
+	Text  string
+	Bold  bool
+	Color color.RGBA
+	Gap   float64 // extra space above the paragraph, in mm
+}
+
+// Font sizes of the PDF's text, in points.
+const (
+	pdfMaxFontSize = 10.0
+	pdfMinFontSize = 5.0
+)
+
+// renderPDF renders an A4 overview of the week for printing, with a row per
+// weekday and the canteens side by side. The font size is reduced until the
+// week fits on one page, down to pdfMinFontSize; a longer week continues on
+// further pages. It fails if a single day doesn't fit on a page.
+func renderPDF(days []DayMenus, title string) ([]byte, error) {
+	const (
+		pageWidth  = 210.0
+		pageHeight = 297.0
+		margin     = 12.0
+		labelCol   = 24.0
+		padding    = 2.0
+	)
+	colWidth := (pageWidth - 2*margin - labelCol) / float64(max(len(sources), 1))
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("go-menu-extractor", true)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddUTF8FontFromBytes("Go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("Go", "B", gobold.TTF)
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("error loading fonts: %w", err)
+	}
+
+	cells := make([][][]pdfLine, len(days))
+	for i, day := range days {
+		for _, menu := range day.Canteens() {
+			cells[i] = append(cells[i], pdfMenuLines(menu))
+		}
+	}
+
+	// Find the largest font size at which all rows fit on the page. A week
+	// that doesn't fit even at the smallest size continues on more pages.
+	layout := func(size float64) (rowHeights []float64, total float64) {
+		lineHeight := size * 0.45
+		total = margin + 2*size*0.6 + lineHeight + 2*padding
+		for _, row := range cells {
+			h := 0.0
+			for _, lines := range row {
+				h = max(h, pdfCellHeight(pdf, lines, size, lineHeight, colWidth-2*padding))
+			}
+			h += 2 * padding
+			rowHeights = append(rowHeights, h)
+			total += h
+		}
+		return rowHeights, total
+	}
+	size := pdfMaxFontSize
+	rowHeights, total := layout(size)
+	for total > pageHeight-margin && size > pdfMinFontSize {
+		size -= 0.5
+		rowHeights, total = layout(size)
+	}
+	lineHeight := size * 0.45
+	headerHeight := lineHeight + padding
+	for i, h := range rowHeights {
+		if margin+headerHeight+h > pageHeight-margin {
+			return nil, fmt.Errorf("the menus of %s don't fit on a page", days[i].Name)
+		}
+	}
+
+	setColor := func(c color.RGBA) { pdf.SetTextColor(int(c.R), int(c.G), int(c.B)) }
+	setFill := func(c color.RGBA) { pdf.SetFillColor(int(c.R), int(c.G), int(c.B)) }
+	// header draws the canteen names at y, on every page.
+	header := func(y float64) float64 {
+		pdf.SetFont("Go", "B", size)
+		setColor(imagePrimary)
+		for i, menu := range days[0].Canteens() {
+			x := margin + labelCol + float64(i)*colWidth + padding
+			pdf.Text(x, y+lineHeight, menu.Source.Name)
+			setFill(sourceColor(menu.Source))
+			pdf.Rect(x, y+lineHeight+0.8, colWidth-2*padding, 0.8, "F")
+		}
+		return y + headerHeight
+	}
+
+	pdf.AddPage()
+	y := margin
+	pdf.SetFont("Go", "B", size*2)
+	setColor(imagePrimary)
+	pdf.Text(margin, y+size*0.8, title)
+	y += 2*size*0.6 + padding
+	y = header(y)
+
+	pdf.SetDrawColor(int(imageMuted.R), int(imageMuted.G), int(imageMuted.B))
+	pdf.SetLineWidth(0.2)
+	for i, day := range days {
+		if y+rowHeights[i] > pageHeight-margin {
+			pdf.Line(margin, y, pageWidth-margin, y)
+			pdf.AddPage()
+			y = header(margin)
+		}
+		if i%2 == 1 {
+			setFill(imageBackground)
+			pdf.Rect(margin, y, pageWidth-2*margin, rowHeights[i], "F")
+		}
+		pdf.Line(margin, y, pageWidth-margin, y)
+		pdf.SetFont("Go", "B", size)
+		setColor(imagePrimary)
+		pdf.SetXY(margin, y+padding)
+		pdf.MultiCell(labelCol, lineHeight, day.Name, "", "L", false)
+		pdf.SetFont("Go", "", size*0.85)
+		setColor(imageMuted)
+		pdf.SetX(margin)
+		pdf.MultiCell(labelCol, lineHeight, day.Date.Format("02.01."), "", "L", false)
+		for c, lines := range cells[i] {
+			pdfDrawLines(pdf, lines, size, lineHeight, margin+labelCol+float64(c)*colWidth+padding, y+padding, colWidth-2*padding)
+		}
+		y += rowHeights[i]
+	}
+	pdf.Line(margin, y, pageWidth-margin, y)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("error writing PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfMenuLines lists the categories and dishes of one canteen on one day.
+func pdfMenuLines(menu MenuView) []pdfLine {
+	var lines []pdfLine
+	for _, annotation := range menu.Annotations {
+		lines = append(lines, pdfLine{Text: annotation.String(), Color: imageMuted})
+	}
+	if menu.Closed != "" {
+		return append(lines, pdfLine{Text: menu.Closed, Color: imageMuted})
+	}
+	if len(menu.Categories) == 0 {
+		return append(lines, pdfLine{Text: "No menu data", Color: imageMuted})
+	}
+	for i, category := range menu.Categories {
+		gap := 0.0
+		if i > 0 || len(lines) > 0 {
+			gap = 1.5
+		}
+		lines = append(lines, pdfLine{Text: category.Name, Bold: true, Color: imagePrimary, Gap: gap})
+		for _, dish := range category.Dishes {
+			text := dish.Title
+			if dish.Price != "" {
+				text += " – " + dish.Price
+			}
+			lines = append(lines, pdfLine{Text: text, Color: imagePrimary})
+		}
+	}
+	return lines
+}
+
+func pdfSetLineFont(pdf *gofpdf.Fpdf, line pdfLine, size float64) {
+	style := ""
+	if line.Bold {
+		style = "B"
+	}
+	pdf.SetFont("Go", style, size)
+}
+
+// pdfCellHeight returns the height of lines wrapped to width.
+func pdfCellHeight(pdf *gofpdf.Fpdf, lines []pdfLine, size, lineHeight, width float64) float64 {
+	h := 0.0
+	for _, line := range lines {
+		pdfSetLineFont(pdf, line, size)
+		h += line.Gap + float64(len(pdf.SplitText(line.Text, width)))*lineHeight
+	}
+	return h
+}
+
+// pdfDrawLines draws lines wrapped to width with the top left corner at
+// (x, y).
+func pdfDrawLines(pdf *gofpdf.Fpdf, lines []pdfLine, size, lineHeight, x, y, width float64) {
+	pdf.SetXY(x, y)
+	for _, line := range lines {
+		pdfSetLineFont(pdf, line, size)
+		pdf.SetTextColor(int(line.Color.R), int(line.Color.G), int(line.Color.B))
+		pdf.SetXY(x, pdf.GetY()+line.Gap)
+		pdf.MultiCell(width, lineHeight, line.Text, "", "L", false)
+	}
+}
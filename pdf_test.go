@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// heavyPlan returns a plan of week 45/2025 listing dishes dishes per
+// weekday, each with a title of words words.
+func heavyPlan(dishes, words int) MenuPlan {
+	category := MenuCategory{Name: "Menü", Menus: make(map[Weekday][]Dish)}
+	for day := Monday; day <= Friday; day++ {
+		for i := range dishes {
+			title := fmt.Sprintf("Gericht %d ", i) + strings.Repeat("mit Beilage ", words)
+			category.Menus[day] = append(category.Menus[day], Dish{TitleDe: title, Price: "€ 6,20"})
+		}
+	}
+	return MenuPlan{Week: "45", Year: 2025, Menus: []MenuCategory{category}}
+}
+
+func TestRenderPDF(t *testing.T) {
+	plans := samplePlans(t)
+	tests := []struct {
+		name  string
+		jku   MenuPlan
+		pages int // 0 for an error
+	}{
+		{"sample", plans["jku"], 1},
+		{"oversized week", heavyPlan(40, 5), 3},
+		{"oversized day", heavyPlan(200, 5), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			week := buildWeekMenu(tt.jku, plans["khg"])
+			output, err := renderPDF(buildDayMenus(week), week.Title())
+			if tt.pages == 0 {
+				if err == nil {
+					t.Error("no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pages := bytes.Count(output, []byte("/Type /Page\n")); pages != tt.pages {
+				t.Errorf("%d pages, want %d", pages, tt.pages)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package menu
+
+import "fmt"
+
+// Annotation levels.
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+)
+
+// Annotation is a notice about the data behind a menu, such as a source
+// falling back to its cache, meant to be shown next to the menu instead of
+// being left to the logs.
+type Annotation struct {
+	Level   string `json:"level"`  // LevelInfo or LevelWarning
+	Source  string `json:"source"` // ID of the source it concerns
+	Message string `json:"message"`
+}
+
+// String formats the annotation for plain-text outputs.
+func (a Annotation) String() string {
+	if a.Level == LevelWarning {
+		return "Warning: " + a.Message
+	}
+	return "Note: " + a.Message
+}
+
+// Annotate adds an annotation to the plan. Its source is left for the
+// caller to fill in.
+func (p *Plan) Annotate(level, format string, args ...any) {
+	p.Annotations = append(p.Annotations, Annotation{Level: level, Message: fmt.Sprintf(format, args...)})
+}
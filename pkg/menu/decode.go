@@ -0,0 +1,153 @@
+package menu
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DecodePlan decodes the stringified menuplanCurrentWeek JSON of mensen.at.
+// The upstream payload is produced by PHP and not entirely consistent:
+// empty day maps come through as [], prices are sometimes numbers, and
+// individual entries occasionally carry unexpected types. Categories, days and dishes
+// that cannot be decoded are skipped and returned as warnings, so a single
+// malformed entry doesn't discard the whole week. Only a payload that isn't
+// a JSON object at all is an error.
+func DecodePlan(data []byte) (Plan, []error, error) {
+	var raw struct {
+		Week  json.RawMessage   `json:"week"`
+		Year  json.RawMessage   `json:"year"`
+		Menus []json.RawMessage `json:"menus"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Plan{}, nil, err
+	}
+
+	var plan Plan
+	var warnings []error
+	week, err := decodeFlexString(raw.Week)
+	if err != nil {
+		warnings = append(warnings, fmt.Errorf("week: %w", err))
+	}
+	plan.Week = week
+	year, err := decodeFlexString(raw.Year)
+	if err != nil {
+		warnings = append(warnings, fmt.Errorf("year: %w", err))
+	}
+	if year != "" {
+		if _, err := fmt.Sscan(year, &plan.Year); err != nil {
+			warnings = append(warnings, fmt.Errorf("year %q: %w", year, err))
+		}
+	}
+
+	for i, rawCategory := range raw.Menus {
+		category, categoryWarnings, err := decodeMenuCategory(rawCategory)
+		if err != nil {
+			warnings = append(warnings, fmt.Errorf("category %d: %w", i, err))
+			continue
+		}
+		warnings = append(warnings, categoryWarnings...)
+		plan.Menus = append(plan.Menus, category)
+	}
+	return plan, warnings, nil
+}
+
+func decodeMenuCategory(data json.RawMessage) (Category, []error, error) {
+	var raw struct {
+		Name  json.RawMessage `json:"name"`
+		Menus json.RawMessage `json:"menus"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Category{}, nil, err
+	}
+	name, err := decodeFlexString(raw.Name)
+	if err != nil {
+		return Category{}, nil, fmt.Errorf("name: %w", err)
+	}
+	category := Category{Name: name, Menus: make(map[Weekday][]Dish)}
+
+	// A category without any dishes this week has no "menus" key or an
+	// empty array in place of the day map.
+	if isEmptyJSON(raw.Menus) {
+		return category, nil, nil
+	}
+	var days map[string][]json.RawMessage
+	if err := json.Unmarshal(raw.Menus, &days); err != nil {
+		return Category{}, nil, fmt.Errorf("%q: menus: %w", name, err)
+	}
+
+	var warnings []error
+	for dayKey, rawDishes := range days {
+		n, err := strconv.Atoi(dayKey)
+		if err != nil || n < int(Monday) || n > int(Sunday) {
+			warnings = append(warnings, fmt.Errorf("category %q: invalid day %q", name, dayKey))
+			continue
+		}
+		day := Weekday(n)
+		for i, rawDish := range rawDishes {
+			dish, err := decodeDish(rawDish)
+			if err != nil {
+				warnings = append(warnings, fmt.Errorf("category %q day %s dish %d: %w", name, day, i, err))
+				continue
+			}
+			category.Menus[day] = append(category.Menus[day], dish)
+		}
+	}
+	return category, warnings, nil
+}
+
+func decodeDish(data json.RawMessage) (Dish, error) {
+	var raw struct {
+		TitleDe json.RawMessage `json:"title_de"`
+		Price   json.RawMessage `json:"price"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Dish{}, err
+	}
+	title, err := decodeFlexString(raw.TitleDe)
+	if err != nil {
+		return Dish{}, fmt.Errorf("title_de: %w", err)
+	}
+	price, err := decodeFlexString(raw.Price)
+	if err != nil {
+		return Dish{}, fmt.Errorf("price: %w", err)
+	}
+	return Dish{TitleDe: title, Price: price}, nil
+}
+
+// decodeFlexString accepts a JSON string, number or null and returns it as
+// a string.
+func decodeFlexString(data json.RawMessage) (string, error) {
+	if isEmptyJSON(data) {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s, nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		return n.String(), nil
+	}
+	return "", fmt.Errorf("expected string or number, got %s", truncate(string(data), 40))
+}
+
+// isEmptyJSON reports whether data is absent, null, false, "" or an empty
+// array or object.
+func isEmptyJSON(data json.RawMessage) bool {
+	switch string(bytes.TrimSpace(data)) {
+	case "", "null", "false", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
+
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,44 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Fetchers wrap their errors in one of these so the caller can decide how to
+// react without inspecting messages.
+var (
+	// ErrUpstreamUnavailable means the source could not be reached or
+	// answered with an error status. Retrying may help.
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	// ErrParse means the source answered, but its content could not be
+	// understood. Retrying won't help; a cached plan might.
+	ErrParse = errors.New("unparsable response")
+	// ErrStale means the source returned a plan for an earlier week. The
+	// plan is returned along with the error.
+	ErrStale = errors.New("stale menu")
+	// ErrEmpty means the source returned a plan without any dishes. The plan
+	// is returned along with the error.
+	ErrEmpty = errors.New("empty menu")
+)
+
+// Check reports whether a successfully parsed plan is actually usable: it
+// must contain dishes and must not be for a week that is already over.
+func Check(plan Plan) error {
+	if plan.DishCount() == 0 {
+		return fmt.Errorf("%w: no dishes for week %q", ErrEmpty, plan.Week)
+	}
+	week, err := strconv.Atoi(plan.Week)
+	if err != nil || plan.Year == 0 {
+		// Without a week we can't tell, so give the source the benefit of
+		// the doubt.
+		return nil
+	}
+	year, currentWeek := time.Now().ISOWeek()
+	if plan.Year < year || (plan.Year == year && week < currentWeek) {
+		return fmt.Errorf("%w: plan is for week %d/%d, current week is %d/%d", ErrStale, week, plan.Year, currentWeek, year)
+	}
+	return nil
+}
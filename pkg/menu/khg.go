@@ -0,0 +1,237 @@
+package menu
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// KHGURL is the menu page of the KHG.
+const KHGURL = "https://www.dioezese-linz.at/khg/mensa/menueplan"
+
+// KHG reads the menu page of the KHG.
+type KHG struct {
+	Client *http.Client // nil means a client with a 10 second timeout
+	page   *khgPage
+}
+
+// NewKHG returns a KHG source whose Fetch and FetchNextWeek share the menu
+// page when called within a minute, as they are by a run fetching both
+// weeks.
+func NewKHG(client *http.Client) KHG {
+	return KHG{Client: client, page: &khgPage{}}
+}
+
+// khgPageReuse is how long NewKHG's sources reuse the menu page.
+const khgPageReuse = time.Minute
+
+// khgPage is the menu page last fetched by a KHG source.
+type khgPage struct {
+	mu      sync.Mutex // held while fetching, so concurrent calls wait for it
+	body    []byte
+	fetched time.Time
+}
+
+func (KHG) Name() string {
+	return "KHG website"
+}
+
+// Fetch fetches the plan currently on the menu page.
+func (s KHG) Fetch(ctx context.Context) (Plan, error) {
+	body, err := s.menuPage(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+	menuPlan, err := ParseKHG(bytes.NewReader(body))
+	if err != nil {
+		return Plan{}, err
+	}
+	return menuPlan, Check(menuPlan)
+}
+
+// FetchNextWeek follows the "nächste Woche" link of the menu page, which is
+// there once next week's plan is published, and parses the linked page like
+// the current one. No such page has been recorded yet; the fixtures
+// khg-next-link.html and khg-next.html are the recorded page with a link
+// added and with the following week's heading.
+func (s KHG) FetchNextWeek(ctx context.Context) (Plan, error) {
+	body, err := s.menuPage(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+	link, err := nextWeekLink(bytes.NewReader(body))
+	if err != nil {
+		return Plan{}, err
+	}
+	if body, err = s.get(ctx, link); err != nil {
+		return Plan{}, err
+	}
+	menuPlan, err := ParseKHG(bytes.NewReader(body))
+	if err != nil {
+		return Plan{}, err
+	}
+	return menuPlan, Check(menuPlan)
+}
+
+// menuPage returns the menu page, fetched or reused as NewKHG describes.
+func (s KHG) menuPage(ctx context.Context) ([]byte, error) {
+	if s.page == nil {
+		return s.get(ctx, KHGURL)
+	}
+	s.page.mu.Lock()
+	defer s.page.mu.Unlock()
+	if s.page.body != nil && time.Since(s.page.fetched) < khgPageReuse {
+		return s.page.body, nil
+	}
+	body, err := s.get(ctx, KHGURL)
+	if err != nil {
+		return nil, err
+	}
+	s.page.body, s.page.fetched = body, time.Now()
+	return body, nil
+}
+
+// get fetches a page of the KHG website.
+func (s KHG) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP request: %w", err)
+	}
+	res, err := clientOrDefault(s.Client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch URL %s: %w", ErrUpstreamUnavailable, url, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: bad status code: %d", ErrUpstreamUnavailable, res.StatusCode)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error reading %s: %w", ErrUpstreamUnavailable, url, err)
+	}
+	return body, nil
+}
+
+// nextWeekLink returns the absolute URL of the "nächste Woche" link of the
+// menu page, or an error wrapping ErrEmpty if there is none.
+func nextWeekLink(r io.Reader) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to parse HTML: %w", ErrParse, err)
+	}
+	var href string
+	doc.Find("a[href]").EachWithBreak(func(i int, a *goquery.Selection) bool {
+		text := strings.ToLower(strings.Join(strings.Fields(a.Text()), " "))
+		if strings.Contains(text, "woche") && (strings.Contains(text, "nächste") || strings.Contains(text, "naechste")) {
+			href, _ = a.Attr("href")
+			return false
+		}
+		return true
+	})
+	if href == "" {
+		return "", fmt.Errorf("%w: no link to next week's plan", ErrEmpty)
+	}
+	base, _ := url.Parse(KHGURL)
+	link, err := base.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid link to next week's plan %q: %w", ErrParse, href, err)
+	}
+	return link.String(), nil
+}
+
+// getDayKey converts the German day name to a Weekday.
+func getDayKey(day string) Weekday {
+	switch strings.ToLower(strings.TrimSpace(day)) {
+	case "montag":
+		return Monday
+	case "dienstag":
+		return Tuesday
+	case "mittwoch":
+		return Wednesday
+	case "donnerstag":
+		return Thursday
+	case "freitag":
+		return Friday
+	case "samstag":
+		return Saturday
+	case "sonntag":
+		return Sunday
+	default:
+		return 0 // Invalid day
+	}
+}
+
+var (
+	reWeek = regexp.MustCompile(`KW (\d+)`)
+	reYear = regexp.MustCompile(`(\d{4})`)
+)
+
+// ParseKHG extracts the weekly plan from the KHG menu page.
+func ParseKHG(r io.Reader) (Plan, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Plan{}, fmt.Errorf("%w: failed to parse HTML: %w", ErrParse, err)
+	}
+
+	menuPlan := Plan{
+		Menus: []Category{
+			{Name: "Menü 1", Menus: make(map[Weekday][]Dish)},
+			{Name: "Menü 2", Menus: make(map[Weekday][]Dish)},
+		},
+	}
+
+	headerText := doc.Find(".swslang h4").First().Text()
+
+	if weekMatches := reWeek.FindStringSubmatch(headerText); len(weekMatches) > 1 {
+		menuPlan.Week = weekMatches[1]
+	}
+	if yearMatches := reYear.FindStringSubmatch(headerText); len(yearMatches) > 1 {
+		if year, err := strconv.Atoi(yearMatches[1]); err == nil {
+			menuPlan.Year = year
+		}
+	}
+
+	// Process the menu table
+	var currentDayKey Weekday
+	var dishCounterForDay int // 0 for Menü 1, 1 for Menü 2
+
+	doc.Find("table.sweTable1 tbody tr").Each(func(i int, row *goquery.Selection) {
+
+		// Day header row (e.g., "Montag")
+		if row.HasClass("sweTableRow1") {
+			dayName := row.Find("strong").Text()
+			currentDayKey = getDayKey(dayName)
+			dishCounterForDay = 0
+			return
+		}
+
+		// Dish row: has 3 <td> children
+		cells := row.Find("td")
+		if cells.Length() == 3 && currentDayKey != 0 {
+			title := strings.TrimSpace(cells.Eq(0).Text())
+			price := strings.TrimSpace(cells.Eq(1).Text())
+			dish := Dish{
+				TitleDe: title,
+				Price:   price,
+			}
+			if dishCounterForDay < len(menuPlan.Menus) {
+				category := &menuPlan.Menus[dishCounterForDay]
+				category.Menus[currentDayKey] = append(category.Menus[currentDayKey], dish)
+				dishCounterForDay++
+			}
+		}
+	})
+
+	return menuPlan, nil
+}
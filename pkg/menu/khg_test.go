@@ -0,0 +1,110 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const khgNextWeekURL = "https://www.dioezese-linz.at/khg/mensa/menueplan/naechste-woche"
+
+// fixtureTransport answers requests with the files of samplereqresp, by
+// URL, and records the requested URLs.
+type fixtureTransport struct {
+	files map[string]string
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, req.URL.String())
+	t.mu.Unlock()
+	name, ok := t.files[req.URL.String()]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+	f, err := os.Open(filepath.Join("..", "..", "samplereqresp", name))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: f, Request: req}, nil
+}
+
+func TestNextWeekLink(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+		err  error
+	}{
+		{"khg.html", "", ErrEmpty},
+		{"khg-next-link.html", khgNextWeekURL, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("..", "..", "samplereqresp", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := nextWeekLink(f)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("nextWeekLink() = %q, %v, want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestKHGFetchNextWeek(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		week  string
+		err   error
+	}{
+		// The fixtures are from 2025, so a parsed plan is stale.
+		{"published", map[string]string{KHGURL: "khg-next-link.html", khgNextWeekURL: "khg-next.html"}, "46", ErrStale},
+		{"not yet published", map[string]string{KHGURL: "khg.html"}, "", ErrEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &fixtureTransport{files: tt.files}
+			plan, err := KHG{Client: &http.Client{Transport: transport}}.FetchNextWeek(context.Background())
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if plan.Week != tt.week {
+				t.Errorf("week = %q, want %q", plan.Week, tt.week)
+			}
+			if tt.week != "" && plan.DishCount() == 0 {
+				t.Error("no dishes")
+			}
+		})
+	}
+}
+
+func TestKHGSharesMenuPage(t *testing.T) {
+	transport := &fixtureTransport{files: map[string]string{KHGURL: "khg-next-link.html", khgNextWeekURL: "khg-next.html"}}
+	s := NewKHG(&http.Client{Transport: transport})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); s.Fetch(context.Background()) }()
+	go func() { defer wg.Done(); s.FetchNextWeek(context.Background()) }()
+	wg.Wait()
+	pages := 0
+	for _, url := range transport.requests {
+		if url == KHGURL {
+			pages++
+		}
+	}
+	if pages != 1 || len(transport.requests) != 2 {
+		t.Errorf("requests = %q, want the menu page once and next week's page", transport.requests)
+	}
+}
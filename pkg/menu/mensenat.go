@@ -0,0 +1,375 @@
+package menu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// MensenAtURL is the GraphQL endpoint of mensen.at.
+const MensenAtURL = "https://backend.mensen.at/api"
+
+// MensenAt reads a location of the mensen.at API, e.g.
+// "standort/mensa-jku/". Locations lists the available ones.
+type MensenAt struct {
+	Location string
+	Client   *http.Client // nil means a client with a 10 second timeout
+	// SchemaBaseline, if set, is a file keeping the key paths of the last
+	// payload, so changes to the upstream format are logged. Each location
+	// and week gets its own file, named with the location and the menuplan
+	// field before the extension, e.g. mensen-schema.json becomes
+	// mensen-schema.standort-mensa-jku.menuplanCurrentWeek.json.
+	SchemaBaseline string
+}
+
+func (s MensenAt) Name() string {
+	return "mensen.at " + s.Location
+}
+
+// Fetch fetches the plan of the current week. Its Title is the location's
+// name as published.
+func (s MensenAt) Fetch(ctx context.Context) (Plan, error) {
+	return s.fetch(ctx, "menuplanCurrentWeek")
+}
+
+// FetchNextWeek fetches the plan of next week, which mensen.at publishes
+// some time before the week starts.
+func (s MensenAt) FetchNextWeek(ctx context.Context) (Plan, error) {
+	return s.fetch(ctx, "menuplanNextWeek")
+}
+
+// fetch fetches the plan in field, one of the menuplan fields of a Location.
+func (s MensenAt) fetch(ctx context.Context, field string) (Plan, error) {
+	query := `query Location($locationUri: String!, $weekDay: String!) {
+	  nodeByUri(uri: $locationUri) {
+		... on Location {
+		  menuplan: ` + field + `
+		  openingHour(day: $weekDay) {
+			nowDate
+			nowWeekDay
+			status
+			from
+			to
+			closed
+			reopen
+		  }
+		  title
+		  uri
+		}
+	  }
+	}`
+
+	payload := graphQLRequest{
+		Query: query,
+		Variables: map[string]any{
+			"locationUri": s.Location,
+			"weekDay":     "now",
+		},
+		OperationName: "Location",
+	}
+	var apiResponse struct {
+		Data struct {
+			NodeByUri struct {
+				Title    string `json:"title"`
+				Menuplan string `json:"menuplan"` // This is stringified JSON
+			} `json:"nodeByUri"`
+		} `json:"data"`
+		Errors GraphQLErrors `json:"errors"`
+	}
+	if err := s.query(ctx, payload, &apiResponse); err != nil {
+		return Plan{}, err
+	}
+
+	// GraphQL reports failures with a 200 status and an "errors" array. If
+	// the menu plan still came through, the errors concern other fields and
+	// the data is usable. Otherwise the query itself failed, which retrying
+	// won't change, so it counts as an unusable response.
+	menuString := apiResponse.Data.NodeByUri.Menuplan
+	if len(apiResponse.Errors) > 0 {
+		if menuString == "" {
+			return Plan{}, fmt.Errorf("%w: GraphQL error: %w", ErrParse, apiResponse.Errors)
+		}
+		log.Printf("mensen.at API returned partial data for %s with errors: %v", s.Location, apiResponse.Errors)
+	}
+	if menuString == "" {
+		return Plan{}, fmt.Errorf("%w: no %s in response for location %q", ErrEmpty, field, s.Location)
+	}
+
+	if s.SchemaBaseline != "" {
+		if drift, err := checkSchemaDrift(s.schemaBaseline(field), []byte(menuString)); err != nil {
+			log.Printf("Error checking mensen.at schema: %v", err)
+		} else if drift != "" {
+			log.Printf("WARNING: mensen.at payload schema of %s %s changed: %s", s.Location, field, drift)
+		}
+	}
+
+	currentWeekMenu, warnings, err := DecodePlan([]byte(menuString))
+	if err != nil {
+		return Plan{}, fmt.Errorf("%w: error unmarshaling inner menu JSON: %w\nString was: %s", ErrParse, err, menuString)
+	}
+	for _, warning := range warnings {
+		log.Printf("Skipping malformed entry of %s: %v", s.Location, warning)
+	}
+	if len(warnings) > 0 {
+		currentWeekMenu.Annotate(LevelInfo, "%d malformed entries were skipped", len(warnings))
+	}
+
+	currentWeekMenu.Title = strings.TrimSpace(apiResponse.Data.NodeByUri.Title)
+	currentWeekMenu.Location = s.Location
+	return currentWeekMenu, Check(currentWeekMenu)
+}
+
+// schemaBaseline returns the baseline file of the plan in field.
+func (s MensenAt) schemaBaseline(field string) string {
+	ext := filepath.Ext(s.SchemaBaseline)
+	location := strings.ReplaceAll(strings.Trim(s.Location, "/"), "/", "-")
+	return strings.TrimSuffix(s.SchemaBaseline, ext) + "." + location + "." + field + ext
+}
+
+// Location is a location listed by mensen.at.
+type Location struct {
+	Title string `json:"title"`
+	URI   string `json:"uri"` // for MensenAt.Location
+	City  string `json:"city"`
+}
+
+const locationsQuery = `query Locations($after: String) {
+  locations(first: 100, after: $after) {
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    nodes {
+      title
+      uri
+      locationData {
+        address {
+          city
+        }
+      }
+    }
+  }
+}`
+
+// Locations lists all locations of the mensen.at API, page by page. Only
+// the Client of s is used.
+func (s MensenAt) Locations(ctx context.Context) ([]Location, error) {
+	var locations []Location
+	var after any // null for the first page
+	for {
+		payload := graphQLRequest{
+			Query:         locationsQuery,
+			Variables:     map[string]any{"after": after},
+			OperationName: "Locations",
+		}
+		var response struct {
+			Data struct {
+				Locations struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						Title        string `json:"title"`
+						URI          string `json:"uri"`
+						LocationData struct {
+							Address struct {
+								City string `json:"city"`
+							} `json:"address"`
+						} `json:"locationData"`
+					} `json:"nodes"`
+				} `json:"locations"`
+			} `json:"data"`
+			Errors GraphQLErrors `json:"errors"`
+		}
+		if err := s.query(ctx, payload, &response); err != nil {
+			return nil, err
+		}
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("GraphQL error: %w", response.Errors)
+		}
+		for _, node := range response.Data.Locations.Nodes {
+			locations = append(locations, Location{
+				Title: strings.TrimSpace(node.Title),
+				URI:   strings.Trim(node.URI, "/") + "/",
+				City:  strings.TrimSpace(node.LocationData.Address.City),
+			})
+		}
+		page := response.Data.Locations.PageInfo
+		if !page.HasNextPage || page.EndCursor == "" {
+			break
+		}
+		after = page.EndCursor
+	}
+	return locations, nil
+}
+
+// SchemaField is a field of a type in the mensen.at GraphQL schema.
+type SchemaField struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"` // in GraphQL notation, e.g. "[String]!"
+	Args        []string `json:"args,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+const typeQuery = `query Type($name: String!) {
+  __type(name: $name) {
+    fields {
+      name
+      description
+      args {
+        name
+        type { ...TypeRef }
+      }
+      type { ...TypeRef }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+      }
+    }
+  }
+}`
+
+// typeRef is a GraphQL type reference as returned by introspection.
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+func (t *typeRef) String() string {
+	switch {
+	case t == nil:
+		return "?"
+	case t.Kind == "NON_NULL":
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST":
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+// Fields returns the fields of the type called name, e.g. "Location", by
+// introspecting the schema. It fails if the API doesn't allow
+// introspection. Only the Client of s is used.
+func (s MensenAt) Fields(ctx context.Context, name string) ([]SchemaField, error) {
+	var response struct {
+		Data struct {
+			Type *struct {
+				Fields []struct {
+					Name        string `json:"name"`
+					Description string `json:"description"`
+					Args        []struct {
+						Name string   `json:"name"`
+						Type *typeRef `json:"type"`
+					} `json:"args"`
+					Type *typeRef `json:"type"`
+				} `json:"fields"`
+			} `json:"__type"`
+		} `json:"data"`
+		Errors GraphQLErrors `json:"errors"`
+	}
+	payload := graphQLRequest{
+		Query:         typeQuery,
+		Variables:     map[string]any{"name": name},
+		OperationName: "Type",
+	}
+	if err := s.query(ctx, payload, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("GraphQL error: %w", response.Errors)
+	}
+	if response.Data.Type == nil {
+		return nil, fmt.Errorf("no type %q in the schema", name)
+	}
+	var fields []SchemaField
+	for _, f := range response.Data.Type.Fields {
+		field := SchemaField{Name: f.Name, Type: f.Type.String(), Description: strings.TrimSpace(f.Description)}
+		for _, arg := range f.Args {
+			field.Args = append(field.Args, arg.Name+": "+arg.Type.String())
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// GraphQLError is a single entry of the GraphQL "errors" array.
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path"`
+}
+
+// GraphQLErrors is the GraphQL "errors" array of a response.
+type GraphQLErrors []GraphQLError
+
+func (errs GraphQLErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Message
+		if len(e.Path) > 0 {
+			messages[i] += fmt.Sprintf(" (at %v)", e.Path)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// query posts a GraphQL request to the mensen.at API and decodes the
+// response into v.
+func (s MensenAt) query(ctx context.Context, payload graphQLRequest, v any) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshaling request payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", MensenAtURL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("error creating HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := clientOrDefault(s.Client).Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: error sending HTTP request: %w", ErrUpstreamUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: error reading response body: %w", ErrUpstreamUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: API request failed with status: %s\nResponse: %s", ErrUpstreamUnavailable, resp.Status, string(body))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: error unmarshaling outer JSON: %w\nBody: %s", ErrParse, err, string(body))
+	}
+	return nil
+}
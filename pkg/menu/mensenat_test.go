@@ -0,0 +1,46 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// responseTransport answers every request with status and body.
+type responseTransport struct {
+	status int
+	body   string
+}
+
+func (t responseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: t.status, Status: http.StatusText(t.status), Body: io.NopCloser(strings.NewReader(t.body)), Request: req}, nil
+}
+
+func TestMensenAtFetchErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport responseTransport
+		err       error
+	}{
+		{"status", responseTransport{http.StatusBadGateway, ""}, ErrUpstreamUnavailable},
+		{"outer JSON", responseTransport{http.StatusOK, "<html>"}, ErrParse},
+		{"GraphQL error", responseTransport{http.StatusOK, `{"errors":[{"message":"Cannot query field \"menuplanCurrentWeek\""}],"data":null}`}, ErrParse},
+		{"no plan", responseTransport{http.StatusOK, `{"data":{"nodeByUri":{"title":"Mensa","menuplan":""}}}`}, ErrEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := MensenAt{Location: "standort/mensa-jku/", Client: &http.Client{Transport: tt.transport}}
+			_, err := s.Fetch(context.Background())
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			// Only unavailable upstreams are retried.
+			if tt.err != ErrUpstreamUnavailable && errors.Is(err, ErrUpstreamUnavailable) {
+				t.Errorf("error = %v, retryable", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package menu fetches the weekly menus of the canteens shown by
+// go-menu-extractor: the mensen.at locations, such as the JKU Mensa, and the
+// KHG. Programs that only need the data, like bots or dashboards, can use it
+// instead of running the binary:
+//
+//	plan, err := menu.MensenAt{Location: "standort/mensa-jku/"}.Fetch(ctx)
+//
+// Every fetcher implements Source and returns a Plan, the week's dishes by
+// category and weekday as published by the canteen.
+package menu
+
+import (
+	"strconv"
+	"time"
+)
+
+// Plan is the menu of one canteen for one week. It matches the JSON
+// published by mensen.at.
+type Plan struct {
+	Week  string     `json:"week"`
+	Year  int        `json:"year"`
+	Menus []Category `json:"menus"`
+
+	// Title and Location are only set for mensen.at plans: the canteen's
+	// name as published and the location it was fetched from.
+	Title    string `json:"title,omitempty"`
+	Location string `json:"location,omitempty"`
+
+	Annotations []Annotation `json:"-"` // notices from fetching, not cached
+}
+
+// Category is a line of a canteen, e.g. "Menü 1", with its dishes by day.
+type Category struct {
+	Name  string             `json:"name"`
+	Menus map[Weekday][]Dish `json:"menus"` // Encoded as "1" (Monday) … "7" (Sunday)
+}
+
+// Dish is a dish as published. Titles may contain HTML line breaks, and
+// prices are unformatted, e.g. "5,20" or "6.20".
+type Dish struct {
+	TitleDe string `json:"title_de"`
+	Price   string `json:"price"`
+}
+
+// Weekday is a day of the week numbered like the upstream payload and ISO
+// 8601: 1 is Monday, 7 is Sunday.
+type Weekday int
+
+const (
+	Monday Weekday = iota + 1
+	Tuesday
+	Wednesday
+	Thursday
+	Friday
+	Saturday
+	Sunday
+)
+
+func (d Weekday) String() string {
+	return time.Weekday(d % 7).String()
+}
+
+// WeekdayOf returns the Weekday of t.
+func WeekdayOf(t time.Time) Weekday {
+	return Weekday((int(t.Weekday())+6)%7 + 1)
+}
+
+// WeekStart returns the Monday of the plan's week, or false if the plan
+// doesn't say which week it is for.
+func (p Plan) WeekStart() (time.Time, bool) {
+	week, err := strconv.Atoi(p.Week)
+	if err != nil || week < 1 || week > 53 || p.Year == 0 {
+		return time.Time{}, false
+	}
+	return ISOWeekStart(p.Year, week, time.Local), true
+}
+
+// Day returns the Weekday under which the plan lists the dishes of date, or
+// false if date is outside the plan's week. Plans without a week are assumed
+// to be for the week of date.
+func (p Plan) Day(date time.Time) (Weekday, bool) {
+	start, ok := p.WeekStart()
+	if !ok {
+		return WeekdayOf(date), true
+	}
+	offset := daysBetween(start, date)
+	if offset < 0 || offset > 6 {
+		return 0, false
+	}
+	return Weekday(offset + 1), true
+}
+
+// DishCount returns the number of dishes in the plan.
+func (p Plan) DishCount() int {
+	n := 0
+	for _, category := range p.Menus {
+		for _, dishes := range category.Menus {
+			n += len(dishes)
+		}
+	}
+	return n
+}
+
+// daysBetween returns the number of calendar days from a to b.
+func daysBetween(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// ISOWeekStart returns the Monday of the given ISO week.
+func ISOWeekStart(year, week int, loc *time.Location) time.Time {
+	// January 4th is always in week 1.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, (week-1)*7)
+}
@@ -0,0 +1,124 @@
+package menu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// dynamicKeyParents are objects whose keys are data rather than schema (day
+// numbers, allergen letters, label names); their keys collapse to "*".
+var dynamicKeyParents = map[string]bool{
+	"menus":        true,
+	"allergens":    true,
+	"informations": true,
+}
+
+// schemaMu serializes drift checks, as concurrent fetches may share a
+// baseline file.
+var schemaMu sync.Mutex
+
+// checkSchemaDrift compares the key paths in payload against the baseline
+// stored in baselineFile and returns a description of the drift, or "" if
+// there is none. The baseline is replaced with the current keys afterwards,
+// so each change is reported once.
+func checkSchemaDrift(baselineFile string, payload []byte) (string, error) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+
+	var doc interface{}
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return "", fmt.Errorf("error decoding payload for schema check: %w", err)
+	}
+	observed := make(map[string]bool)
+	collectKeyPaths(doc, "", "", observed)
+
+	var baseline []string
+	data, err := os.ReadFile(baselineFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// First run: nothing to compare against.
+	case err != nil:
+		return "", fmt.Errorf("error reading schema baseline: %w", err)
+	default:
+		if err := json.Unmarshal(data, &baseline); err != nil {
+			return "", fmt.Errorf("error decoding schema baseline %s: %w", baselineFile, err)
+		}
+	}
+
+	current := make([]string, 0, len(observed))
+	for path := range observed {
+		current = append(current, path)
+	}
+	sort.Strings(current)
+
+	var drift string
+	if baseline != nil {
+		known := make(map[string]bool, len(baseline))
+		var missing []string
+		for _, path := range baseline {
+			known[path] = true
+			if !observed[path] {
+				missing = append(missing, path)
+			}
+		}
+		var added []string
+		for _, path := range current {
+			if !known[path] {
+				added = append(added, path)
+			}
+		}
+		var parts []string
+		if len(added) > 0 {
+			parts = append(parts, "new keys: "+strings.Join(added, ", "))
+		}
+		if len(missing) > 0 {
+			parts = append(parts, "missing keys: "+strings.Join(missing, ", "))
+		}
+		drift = strings.Join(parts, "; ")
+	}
+
+	if baseline == nil || drift != "" {
+		data, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			return drift, err
+		}
+		if err := os.MkdirAll(filepath.Dir(baselineFile), 0755); err != nil {
+			return drift, fmt.Errorf("error creating schema baseline directory: %w", err)
+		}
+		if err := os.WriteFile(baselineFile, append(data, '\n'), 0644); err != nil {
+			return drift, fmt.Errorf("error writing schema baseline: %w", err)
+		}
+	}
+	return drift, nil
+}
+
+// collectKeyPaths records the path of every object key below v, e.g.
+// "menus[].menus.*[].title_de".
+func collectKeyPaths(v interface{}, path, parentKey string, paths map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			name := key
+			if dynamicKeyParents[parentKey] {
+				name = "*"
+			}
+			childPath := name
+			if path != "" {
+				childPath = path + "." + name
+			}
+			paths[childPath] = true
+			collectKeyPaths(child, childPath, key, paths)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectKeyPaths(child, path+"[]", "", paths)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package menu
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Source fetches the weekly plan of one canteen. Fetch returns errors
+// wrapping ErrUpstreamUnavailable, ErrParse, ErrStale or ErrEmpty; with the
+// last two the plan is returned as well.
+type Source interface {
+	// Name says where the plan comes from, for log messages.
+	Name() string
+	Fetch(ctx context.Context) (Plan, error)
+}
+
+// NextWeekSource is a Source that can also fetch the plan of the following
+// week, once the canteen has published it. Until then FetchNextWeek returns
+// an error wrapping ErrEmpty.
+type NextWeekSource interface {
+	Source
+	FetchNextWeek(ctx context.Context) (Plan, error)
+}
+
+// defaultClient is used by sources without an HTTP client of their own.
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+func clientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return defaultClient
+	}
+	return client
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PriceFormat controls how prices are displayed.
+type PriceFormat struct {
+	Symbol      string // currency symbol of the source prices
+	SymbolAfter bool   // "6,20 €" instead of "€ 6,20"
+	// SecondaryCurrency, if set, is shown after the price converted at
+	// SecondaryRate units per euro, e.g. "€ 6,20 (≈ 155,00 CZK)".
+	SecondaryCurrency string
+	SecondaryRate     float64
+}
+
+var priceFormat = PriceFormat{Symbol: "€"}
+
+// parsePrice parses prices like "6.20", "5,20" or "€ 6,20" into cents.
+func parsePrice(raw string) (int, bool) {
+	s := strings.TrimSpace(strings.ReplaceAll(raw, "€", ""))
+	s = strings.ReplaceAll(s, ",", ".")
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return int(math.Round(value * 100)), true
+}
+
+// formatAmount formats cents with a decimal comma, e.g. 620 -> "6,20".
+func formatAmount(cents int) string {
+	return fmt.Sprintf("%d,%02d", cents/100, cents%100)
+}
+
+// formatPrice is the shared price formatter used by all outputs. Prices that
+// can't be parsed are shown as they came from the source; empty prices stay
+// empty.
+func formatPrice(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	cents, ok := parsePrice(raw)
+	if !ok {
+		return raw
+	}
+	price := priceFormat.Symbol + " " + formatAmount(cents)
+	if priceFormat.SymbolAfter {
+		price = formatAmount(cents) + " " + priceFormat.Symbol
+	}
+	if priceFormat.SecondaryCurrency != "" && priceFormat.SecondaryRate > 0 {
+		converted := int(math.Round(float64(cents) * priceFormat.SecondaryRate))
+		price += fmt.Sprintf(" (≈ %s %s)", formatAmount(converted), priceFormat.SecondaryCurrency)
+	}
+	return price
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"krenn.dev/menu/pkg/menu"
+)
+
+// fetchedLocationFields are the Location fields that menu.MensenAt queries.
+var fetchedLocationFields = []string{"menuplanCurrentWeek", "menuplanNextWeek", "openingHour", "title", "uri"}
+
+// probeCommand implements the probe command: it lists the fields of a type
+// in the mensen.at GraphQL schema, so newly exposed data such as next
+// week's plan or nutrition values is noticed.
+func probeCommand(args []string) error {
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	typeName := fs.String("type", "Location", "GraphQL type to list the fields of")
+	asJSON := fs.Bool("json", false, "Print the fields as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	fields, err := menu.MensenAt{Client: httpClient}.Fields(context.Background(), *typeName)
+	if err != nil {
+		return fmt.Errorf("error probing the mensen.at schema (introspection may be disabled): %w", err)
+	}
+	slices.SortFunc(fields, func(a, b menu.SchemaField) int { return strings.Compare(a.Name, b.Name) })
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(fields)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tTYPE\tARGS\tUSED")
+	for _, field := range fields {
+		used := ""
+		if *typeName == "Location" && slices.Contains(fetchedLocationFields, field.Name) {
+			used = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", field.Name, field.Type, strings.Join(field.Args, ", "), used)
+	}
+	return w.Flush()
+}
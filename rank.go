@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// rankWeights are the weights of the criteria the rank command scores
+// dishes by. Each criterion contributes a value between 0 and 1.
+type rankWeights struct {
+	Cheap      float64
+	Vegetarian float64
+	Walk       float64
+}
+
+// rankedDish is a dish of the day with its score.
+type rankedDish struct {
+	Offering
+	Source string
+	Score  float64
+}
+
+// rankCommand implements the rank command: it orders the dishes of a day
+// across all canteens by how well they match the given preferences.
+func rankCommand(args []string) error {
+	fs := flag.NewFlagSet("rank", flag.ExitOnError)
+	var weights rankWeights
+	fs.Float64Var(&weights.Cheap, "cheap", 3, "Weight of a low price")
+	fs.Float64Var(&weights.Vegetarian, "vegetarian", 2, "Weight of a vegetarian or vegan dish")
+	fs.Float64Var(&weights.Walk, "walk", 1, "Weight of a short walk")
+	walkFlag := fs.String("walk-minutes", "", "Walking time to each canteen, e.g. jku=3,khg=8")
+	date := fs.String("date", time.Now().Format("2006-01-02"), "Day to rank")
+	limit := fs.Int("n", 0, "Only show the best n dishes (default: all)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", *date, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date %q: %w", *date, err)
+	}
+	walkMinutes, err := parseWalkMinutes(*walkFlag)
+	if err != nil {
+		return err
+	}
+
+	var offerings []Offering
+	for _, source := range sources {
+		plan, weekday, ok := planForDay(source, day)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "No %s menu for %s\n", source.Name, *date)
+			continue
+		}
+		offerings = append(offerings, dayOfferings(source.ID, plan, weekday, mergeStrategy)...)
+	}
+	if len(offerings) == 0 {
+		return fmt.Errorf("no menus for %s", *date)
+	}
+
+	ranked := rankDishes(offerings, weights, walkMinutes)
+	if *limit > 0 && *limit < len(ranked) {
+		ranked = ranked[:*limit]
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tScore\tCanteen\tPrice\tDish")
+	for i, dish := range ranked {
+		fmt.Fprintf(w, "%d\t%.2f\t%s\t%s\t%s\n", i+1, dish.Score, dish.Source, formatPrice(dish.Dish.Price), plainTitle(dish.Dish.TitleDe))
+	}
+	return w.Flush()
+}
+
+// rankDishes scores offerings and returns them best first. Prices are
+// compared relative to the cheapest and most expensive dish of the day, and
+// walking times relative to the farthest canteen. Canteens missing from
+// walkMinutes get no walk score, like dishes without a price get no price
+// score.
+func rankDishes(offerings []Offering, weights rankWeights, walkMinutes map[string]float64) []rankedDish {
+	minPrice, maxPrice := -1, -1
+	for _, offering := range offerings {
+		if cents, ok := parsePrice(offering.Dish.Price); ok {
+			if minPrice < 0 || cents < minPrice {
+				minPrice = cents
+			}
+			maxPrice = max(maxPrice, cents)
+		}
+	}
+	maxWalk := 0.0
+	for _, minutes := range walkMinutes {
+		maxWalk = max(maxWalk, minutes)
+	}
+
+	var ranked []rankedDish
+	for _, offering := range offerings {
+		var score float64
+		if cents, ok := parsePrice(offering.Dish.Price); ok {
+			cheap := 1.0
+			if maxPrice > minPrice {
+				cheap = 1 - float64(cents-minPrice)/float64(maxPrice-minPrice)
+			}
+			score += weights.Cheap * cheap
+		}
+		if isVegetarian(offering) {
+			score += weights.Vegetarian
+		}
+		if minutes, ok := walkMinutes[offering.SourceID]; ok && maxWalk > 0 {
+			score += weights.Walk * (1 - minutes/maxWalk)
+		}
+		name := offering.SourceID
+		for _, s := range sources {
+			if s.ID == offering.SourceID {
+				name = s.Name
+			}
+		}
+		ranked = append(ranked, rankedDish{Offering: offering, Source: name, Score: score})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked
+}
+
+// isVegetarian guesses from category and title whether a dish is
+// vegetarian; neither source marks this explicitly.
+func isVegetarian(offering Offering) bool {
+	text := strings.ToLower(offering.Category + " " + plainTitle(offering.Dish.TitleDe))
+	for _, keyword := range []string{"veggie", "vegan", "vegetar"} {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWalkMinutes parses a list like "jku=3,khg=8".
+func parseWalkMinutes(s string) (map[string]float64, error) {
+	minutes := make(map[string]float64)
+	if s == "" {
+		return minutes, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		id, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		m, err := strconv.ParseFloat(value, 64)
+		if !ok || err != nil || m < 0 {
+			return nil, fmt.Errorf("invalid walking time %q", pair)
+		}
+		minutes[id] = m
+	}
+	return minutes, nil
+}
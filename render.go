@@ -0,0 +1,427 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"krenn.dev/menu/pkg/menu"
+)
+
+// htmlLayout selects the page generated by the html format.
+var htmlLayout = "tabs"
+
+var htmlLayouts = []string{"tabs", "accessible", "narrow"}
+
+// renderHTML renders the html format in layout.
+func renderHTML(layout string, week WeekMenu, days []DayMenus) ([]byte, error) {
+	switch layout {
+	case "accessible":
+		return renderAccessible(days, week.Title())
+	case "narrow":
+		return renderNarrow(days, week.Title())
+	default:
+		return renderMenusForWeekTabs(days)
+	}
+}
+
+// htmlFormat returns the html format rendering layout instead of
+// htmlLayout.
+func htmlFormat(layout string) outputFormat {
+	format, _ := lookupFormat("html")
+	format.Renderer = RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderHTML(layout, week, days)
+	})
+	return format
+}
+
+// Renderer renders the normalized week. days are the days to show, already
+// narrowed down by -days and -day.
+type Renderer interface {
+	Render(week WeekMenu, days []DayMenus) ([]byte, error)
+}
+
+// RendererFunc adapts a function to the Renderer interface.
+type RendererFunc func(week WeekMenu, days []DayMenus) ([]byte, error)
+
+func (f RendererFunc) Render(week WeekMenu, days []DayMenus) ([]byte, error) {
+	return f(week, days)
+}
+
+// outputFormat is a format selectable with -format.
+type outputFormat struct {
+	Name        string
+	Extension   string // of the default output file
+	ContentType string // when served over HTTP
+	SingleDay   bool   // renders one day, today unless -day selects another
+	NextWeek    bool   // renders next week too, where the sources publish it
+	Renderer    Renderer
+}
+
+// outputFormats are the supported formats in the order they are listed in
+// the help. A new format only needs an entry here.
+var outputFormats = []outputFormat{
+	{"html", ".html", "text/html; charset=utf-8", false, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderHTML(htmlLayout, week, days)
+	})},
+	{"json", ".json", "application/json", false, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		var dates []time.Time
+		for _, day := range days {
+			dates = append(dates, day.Date)
+		}
+		return renderJSON(week, dates)
+	})},
+	{"md", ".md", "text/markdown; charset=utf-8", false, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderMarkdown(days, week.Title()), nil
+	})},
+	{"text", ".txt", "text/plain; charset=utf-8", false, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderText(days, week.Title()), nil
+	})},
+	{"ics", ".ics", "text/calendar; charset=utf-8", false, true, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderICS(days)
+	})},
+	{"atom", ".atom", "application/atom+xml", false, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderAtom(days, week)
+	})},
+	{"jsonfeed", ".feed.json", "application/feed+json", false, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderJSONFeed(days, week)
+	})},
+	{"pdf", ".pdf", "application/pdf", false, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderPDF(days, week.Title())
+	})},
+	{"image-week", ".png", "image/png", false, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderWeekImage(days, week.Title(), outputImageSize.orDefault(imageSize{Width: 1920}))
+	})},
+	{"image-day", ".png", "image/png", true, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderDayImage(days[0], outputImageSize.orDefault(imageSize{Width: 1920, Height: 1080}))
+	})},
+	{"eink", ".png", "image/png", true, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderEinkImage(days[0], outputImageSize.orDefault(imageSize{Width: 800, Height: 480}), false)
+	})},
+	{"eink-raw", ".bin", "application/octet-stream", true, false, RendererFunc(func(week WeekMenu, days []DayMenus) ([]byte, error) {
+		return renderEinkImage(days[0], outputImageSize.orDefault(imageSize{Width: 800, Height: 480}), true)
+	})},
+}
+
+// lookupFormat returns the output format called name.
+func lookupFormat(name string) (outputFormat, bool) {
+	for _, format := range outputFormats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+	return outputFormat{}, false
+}
+
+// parseFormats parses the -format flag, a comma-separated list of formats.
+func parseFormats(list string) ([]outputFormat, error) {
+	var formats []outputFormat
+	for _, name := range strings.Split(list, ",") {
+		format, ok := lookupFormat(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown output format %q", name)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// formatUsage is the help of the -format flag.
+func formatUsage() string {
+	var names []string
+	for _, format := range outputFormats {
+		names = append(names, format.Name)
+	}
+	return "Output formats, separated by commas: " + strings.Join(names, ", ")
+}
+
+const outputUsage = "Output filename, may use {{.Year}}, {{.Week}}, {{.Format}} and {{.Ext}}, - for stdout (default: index with the format's extension, stdout for text)"
+
+// renderOptions are the flags shared by the commands that render menus.
+// Most of them set package-level settings and only take effect once apply
+// has validated them.
+type renderOptions struct {
+	closedFile string
+	days       string
+	weekStart  string
+	week       string
+
+	day      string
+	today    bool
+	tomorrow bool
+}
+
+// addRenderFlags registers the rendering flags on fs.
+func addRenderFlags(fs *flag.FlagSet) *renderOptions {
+	opts := &renderOptions{}
+	fs.StringVar(&htmlLayout, "layout", htmlLayout, "Layout of the html format: tabs, accessible (semantic markup, checked for WCAG contrast) or narrow (single column for phones)")
+	fs.StringVar(&templateDir, "template-dir", "", "Directory with custom templates replacing the built-in ones of the same name, e.g. menu_for_week_tabs.tmpl")
+	fs.Var(&outputImageSize, "image-size", "Resolution of image output as WIDTHxHEIGHT, or WIDTH for automatic height (default: 1920 for image-week, 1920x1080 for image-day, 800x480 for eink)")
+	fs.StringVar(&priceFormat.Symbol, "currency-symbol", priceFormat.Symbol, "Currency symbol shown with prices")
+	fs.BoolVar(&priceFormat.SymbolAfter, "currency-after", false, "Show the currency symbol after the amount")
+	fs.StringVar(&priceFormat.SecondaryCurrency, "secondary-currency", "", "Also show prices in this currency, e.g. CZK")
+	fs.Float64Var(&priceFormat.SecondaryRate, "secondary-rate", 0, "Units of the secondary currency per euro")
+	fs.BoolVar(&asciiOutput, "ascii", false, "Restrict the text and md formats to ASCII: transliterate umlauts, draw tables with +-| and drop emoji")
+	fs.StringVar(&siteURL, "site-url", siteURL, "Public URL of the menu page, used in feeds")
+	fs.StringVar(&opts.day, "day", "", "Only include this day: today, tomorrow, a weekday or YYYY-MM-DD (default: the whole week, today for image-day and eink)")
+	fs.BoolVar(&opts.today, "today", false, "Only include today's menus, same as -day today")
+	fs.BoolVar(&opts.tomorrow, "tomorrow", false, "Only include tomorrow's menus, same as -day tomorrow")
+	fs.StringVar(&opts.closedFile, "closed", "", "File listing dates the canteens are closed, one YYYY-MM-DD per line with an optional reason")
+	fs.StringVar(&opts.days, "days", "mon,tue,wed,thu,fri", "Days to include, e.g. mon,tue,wed,thu")
+	fs.StringVar(&opts.weekStart, "week-start", "monday", "First day of the week: monday or sunday")
+	fs.StringVar(&opts.week, "week", "current", "Week to show: current or next, as far as the sources have published it")
+	fs.StringVar(&mergeStrategy, "merge", mergeStrategy, "How to handle duplicate categories and dishes of a source: "+strings.Join(mergeStrategies, ", "))
+	for name, setting := range brandingFlags {
+		fs.Var(setting, name, setting.Usage)
+	}
+	fs.BoolVar(&portable, "portable", false, "Resolve relative paths against the executable's directory instead of the working directory")
+	return opts
+}
+
+// apply validates the parsed flags and applies the settings that need more
+// than a flag.Value.
+func (opts *renderOptions) apply() error {
+	switch {
+	case opts.today && opts.tomorrow, (opts.today || opts.tomorrow) && opts.day != "":
+		return fmt.Errorf("only one of -day, -today and -tomorrow can be given")
+	case opts.today:
+		opts.day = "today"
+	case opts.tomorrow:
+		opts.day = "tomorrow"
+	}
+	days, err := parseWeekdays(opts.days)
+	if err != nil {
+		return err
+	}
+	displayedWeekdays = days
+	switch start, _ := parseWeekday(opts.weekStart); start {
+	case Monday, Sunday:
+		weekStart = start
+	default:
+		return fmt.Errorf("invalid week start %q, want monday or sunday", opts.weekStart)
+	}
+	switch opts.week {
+	case "current", "next":
+		showNextWeek = opts.week == "next"
+	default:
+		return fmt.Errorf("invalid week %q, want current or next", opts.week)
+	}
+	if opts.closedFile != "" {
+		path, err := resolvePath(opts.closedFile)
+		if err != nil {
+			return err
+		}
+		if closedDates, err = loadClosedDates(path); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains(htmlLayouts, htmlLayout) {
+		return fmt.Errorf("unknown layout %q", htmlLayout)
+	}
+	if !slices.Contains(mergeStrategies, mergeStrategy) {
+		return fmt.Errorf("unknown merge strategy %q", mergeStrategy)
+	}
+	return nil
+}
+
+// defaultOutputFile is the output file of format if -o isn't given: index
+// with the format's extension, or stdout for text.
+func defaultOutputFile(format outputFormat) string {
+	if format.Name == "text" {
+		return "-"
+	}
+	return "index" + format.Extension
+}
+
+// renderWeek renders the week of plans, one per source, in format. Formats
+// showing next week as well get the days of next, if it holds next week's
+// plans; the others show next week instead once this week has no dishes
+// left, see upcomingWeek. If day is set, only that day is rendered, which
+// may be in next week; see selectDay for the values.
+func renderWeek(plans, next []MenuPlan, format outputFormat, day string) ([]byte, error) {
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		return nil, fmt.Errorf("error loading time zone: %w", err)
+	}
+	if day == "" && format.SingleDay {
+		day = "today"
+	}
+	today := DateOf(time.Now().In(vienna))
+	week := buildWeekMenu(plans...)
+	days := buildDayMenus(week)
+	nextWeek := buildWeekMenu(next...)
+	switch {
+	case format.NextWeek || day != "":
+		// Both weeks, so a single day can fall forward into next week.
+		if isNextWeek(week, next) {
+			days = append(days, buildDayMenus(nextWeek)...)
+		}
+	case next != nil && !week.hasDishesFrom(today):
+		// With -week next, there is no later week to fall back to.
+		week = upcomingWeek(week, nextWeek, today)
+		days = buildDayMenus(week)
+	}
+	if day != "" {
+		selected, err := selectDay(days, day, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		days = []DayMenus{selected}
+		if !selected.Date.Before(nextWeek.Monday()) && isNextWeek(week, next) {
+			week = nextWeek
+		}
+	}
+	output, err := format.Renderer.Render(week, days)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering %s: %w", format.Name, err)
+	}
+	return output, nil
+}
+
+// needsNextWeek reports whether rendering formats for day needs next
+// week's plans, whatever this week's are: to show next week, for the
+// formats covering both weeks, or to select a day that may be in next week.
+func needsNextWeek(formats []outputFormat, day string) bool {
+	return showNextWeek || day != "" || slices.ContainsFunc(formats, func(f outputFormat) bool { return f.NextWeek })
+}
+
+// isNextWeek reports whether next holds plans for the week after week.
+func isNextWeek(week WeekMenu, next []MenuPlan) bool {
+	for _, plan := range next {
+		if start, ok := plan.WeekStart(); ok {
+			return DateOf(start) == DateOf(week.Monday()).AddDays(7)
+		}
+	}
+	return false
+}
+
+// upcomingWeek returns what to show instead of week, which has no dishes
+// left: on the weekend, or while the sources still publish last week's plan
+// or none. That is next week as a preview if its plans are out, or else
+// week with a note that nothing is coming, rather than past days alone.
+func upcomingWeek(week, preview WeekMenu, today Date) WeekMenu {
+	if preview.Monday().After(today.In(time.Local)) && preview.hasDishesFrom(today) {
+		preview.annotateSources(menu.LevelInfo, "This week's menu is over, showing next week's")
+		return preview
+	}
+	week.annotateSources(menu.LevelInfo, "There are no more dishes this week, and next week's menu isn't published yet")
+	return week
+}
+
+// writeFormats renders plans, and next week's plans next for the formats
+// showing both or a preview, in each of formats and writes the results to outputFile, or
+// to each format's default file if outputFile is empty. The files written
+// are recorded in report. A format that fails doesn't keep the others from
+// being written.
+func writeFormats(outputFile string, formats []outputFormat, day string, plans, next []MenuPlan, report *RunReport) error {
+	plans, next = shownWeeks(plans, next)
+	paths := make([]string, len(formats))
+	for i, format := range formats {
+		pattern := outputFile
+		if pattern == "" {
+			pattern = defaultOutputFile(format)
+		}
+		path, err := expandOutputPath(pattern, format, plans...)
+		if err != nil {
+			return fmt.Errorf("error expanding output filename: %w", err)
+		}
+		if j := slices.Index(paths[:i], path); j >= 0 {
+			return fmt.Errorf("formats %s and %s would both be written to %s, use {{.Format}} or {{.Ext}} in -o", formats[j].Name, format.Name, path)
+		}
+		paths[i] = path
+	}
+
+	var errs []error
+	for i, format := range formats {
+		output, err := renderWeek(plans, next, format, day)
+		if err == nil {
+			err = writeOutput(paths[i], format.Name, output, report)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeOutput writes output to outputPath and records the file in report.
+// An output path of "-" means stdout.
+func writeOutput(outputPath, format string, output []byte, report *RunReport) error {
+	if outputPath == "-" {
+		if _, err := os.Stdout.Write(output); err != nil {
+			return fmt.Errorf("error writing %s output: %w", format, err)
+		}
+		return nil
+	}
+	outputPath, err := resolvePath(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	if err := os.WriteFile(outputPath, output, 0644); err != nil {
+		return fmt.Errorf("error writing %s output to file: %w", format, err)
+	}
+	report.Outputs = append(report.Outputs, outputPath)
+	return nil
+}
+
+// renderCommand implements the render command: it renders the menus last
+// fetched by fetch (or any other run) without touching the network.
+func renderCommand(args []string) error {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	outputFile := fs.String("o", "", outputUsage)
+	formatList := fs.String("format", "html", formatUsage())
+	lockFile := fs.String("lock", "", "Lock file guarding against overlapping runs writing files (default: no locking)")
+	sourceOpts := addSourceFlags(fs)
+	opts := addRenderFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	formats, err := parseFormats(*formatList)
+	if err != nil {
+		return err
+	}
+	if err := opts.apply(); err != nil {
+		return err
+	}
+	if err := sourceOpts.apply(); err != nil {
+		return err
+	}
+	// Rendering to stdout only may overlap other runs.
+	writesFiles := slices.ContainsFunc(formats, func(f outputFormat) bool {
+		pattern := *outputFile
+		if pattern == "" {
+			pattern = defaultOutputFile(f)
+		}
+		return pattern != "-"
+	})
+	if writesFiles {
+		release, err := lockOrExit(*lockFile)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	var plans []MenuPlan
+	for _, s := range sources {
+		plan, err := loadCachedPlan(s.ID)
+		if err != nil {
+			return fmt.Errorf("no fetched %s menu, run fetch first: %w", s.Name, err)
+		}
+		if plan.Location != s.Location {
+			return fmt.Errorf("the fetched %s menu is from another location, run fetch with the same -location", s.ID)
+		}
+		plans = append(plans, plan)
+	}
+
+	return writeFormats(*outputFile, formats, opts.day, plans, loadCachedNextWeek(), &RunReport{})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RunReport is the machine-readable summary written with -report, meant for
+// monitoring wrappers around scheduled runs.
+type RunReport struct {
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Status   string         `json:"status"` // "ok", "failed" or "skipped"
+	Error    string         `json:"error,omitempty"`
+	Sources  []SourceReport `json:"sources"`
+	Outputs  []string       `json:"outputs"`
+}
+
+// SourceReport describes the outcome of fetching a single source.
+type SourceReport struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"` // "ok", "warning", "cached" or "error"
+	Error      string `json:"error,omitempty"`
+	Attempts   int    `json:"attempts"`
+	DurationMS int64  `json:"duration_ms"`
+	Week       string `json:"week,omitempty"`
+	Year       int    `json:"year,omitempty"`
+	Dishes     int    `json:"dishes"`
+}
+
+// writeReport finishes report and writes it as indented JSON to path.
+func writeReport(path string, report *RunReport, runErr error) error {
+	report.Finished = time.Now()
+	if report.Status == "" {
+		report.Status = "ok"
+		if runErr != nil {
+			report.Status = "failed"
+		}
+	}
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling run report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing run report: %w", err)
+	}
+	return nil
+}
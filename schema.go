@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+
+	"krenn.dev/menu/pkg/menu"
+)
+
+// setSchemaBaseline sets where the mensen.at sources keep the key paths of
+// their last payload, from the -schema-baseline flag: "default" keeps them
+// in the state directory, empty disables drift detection.
+func setSchemaBaseline(value string) error {
+	var path string
+	if value != "default" {
+		resolved, err := resolvePath(value)
+		if err != nil {
+			return err
+		}
+		path = resolved
+	} else if dir, err := stateDir(); err != nil {
+		log.Printf("Schema drift detection disabled: %v", err)
+	} else {
+		path = filepath.Join(dir, "mensen-schema.json")
+	}
+	for i, s := range sources {
+		if source, ok := s.Source.(menu.MensenAt); ok {
+			source.SchemaBaseline = path
+			sources[i].Source = source
+		}
+	}
+	return nil
+}
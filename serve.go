@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// menuServer serves the menus in every output format, refetching the
+// sources in the background. Requests are rendered one at a time, as the
+// feeds keep their state in a file, and the output is cached until the
+// next refresh so kiosks polling the server don't re-render every time.
+// Failures are cached too, so a broken custom template fails fast instead
+// of running again for every request.
+type menuServer struct {
+	mu       sync.Mutex
+	plans    []MenuPlan
+	next     []MenuPlan // next week's, for the formats showing it and previews
+	rendered map[renderKey]renderResult
+}
+
+// renderResult is a cached output or the error rendering it.
+type renderResult struct {
+	output []byte
+	err    error
+}
+
+// maxRendered bounds the cache, as ?day= takes any string and failures
+// are cached too. Once full, it starts over.
+const maxRendered = 64
+
+// renderKey identifies a rendered output by everything a request can vary.
+// It includes the date, as "today" and single-day formats render a
+// different day after midnight.
+type renderKey struct {
+	format string
+	layout string // of the html format
+	day    string
+	date   string
+}
+
+// refresh fetches all sources and replaces the served plans.
+func (s *menuServer) refresh() {
+	plans, next := fetchWeeks(&RunReport{}, true)
+	s.mu.Lock()
+	s.plans = plans
+	s.next = next
+	s.rendered = nil
+	s.mu.Unlock()
+}
+
+// render returns the output of format for day, rendering it if it isn't
+// cached. layout is the html format's layout, if format is html. s.mu must
+// be held.
+func (s *menuServer) render(format outputFormat, layout, day string) ([]byte, error) {
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		return nil, fmt.Errorf("error loading time zone: %w", err)
+	}
+	if format.Name == "html" && layout == "" {
+		layout = htmlLayout
+	}
+	key := renderKey{format: format.Name, layout: layout, day: day, date: time.Now().In(vienna).Format("2006-01-02")}
+	if result, ok := s.rendered[key]; ok {
+		return result.output, result.err
+	}
+	if layout != "" {
+		format = htmlFormat(layout)
+	}
+	plans, next := shownWeeks(s.plans, s.next)
+	output, err := renderWeek(plans, next, format, day)
+	if s.rendered == nil || len(s.rendered) >= maxRendered {
+		s.rendered = make(map[renderKey]renderResult)
+	}
+	s.rendered[key] = renderResult{output, err}
+	return output, err
+}
+
+// ServeHTTP answers / with the html format and /FORMAT with any other
+// format, e.g. /json or /ics. ?day= selects a single day like -day, and
+// ?layout= the layout of the html format.
+func (s *menuServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Path[1:]
+	if name == "" {
+		name = "html"
+	}
+	format, ok := lookupFormat(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var layout string
+	if format.Name == "html" {
+		var err error
+		if layout, err = requestLayout(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hints := strings.Join(append(widthHints, mobileHint), ", ")
+		w.Header().Set("Accept-CH", hints)
+		w.Header().Set("Vary", hints)
+	}
+
+	s.mu.Lock()
+	day := r.URL.Query().Get("day")
+	if r.URL.Query().Has("today") {
+		day = "today"
+	}
+	output, err := s.render(format, layout, day)
+	s.mu.Unlock()
+	var dayErr *dayError
+	switch {
+	case errors.As(err, &dayErr) && dayErr.invalid:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.As(err, &dayErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("Error serving %s: %v", r.URL.Path, err)
+		http.Error(w, "error rendering the menu", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Write(output)
+}
+
+// narrowViewport is the viewport width, in CSS pixels, below which clients
+// get the narrow layout.
+const narrowViewport = 640
+
+// Client hints requestLayout looks at: the viewport width, with and
+// without the Sec-CH- prefix, and whether the browser is on a phone.
+var widthHints = []string{"Sec-CH-Viewport-Width", "Viewport-Width"}
+
+const mobileHint = "Sec-CH-UA-Mobile"
+
+// requestLayout returns the layout of the html format for r: ?layout= if
+// given, otherwise narrow for clients whose hints give a narrow viewport or
+// a phone, as long as the configured layout is tabs.
+func requestLayout(r *http.Request) (string, error) {
+	if layout := r.URL.Query().Get("layout"); layout != "" {
+		if !slices.Contains(htmlLayouts, layout) {
+			return "", fmt.Errorf("unknown layout %q", layout)
+		}
+		return layout, nil
+	}
+	if htmlLayout != "tabs" {
+		return htmlLayout, nil
+	}
+	for _, name := range widthHints {
+		if width, err := strconv.Atoi(r.Header.Get(name)); err == nil {
+			if width < narrowViewport {
+				return "narrow", nil
+			}
+			return htmlLayout, nil
+		}
+	}
+	if r.Header.Get(mobileHint) == "?1" {
+		return "narrow", nil
+	}
+	return htmlLayout, nil
+}
+
+// serveCommand implements the serve command: an HTTP server rendering the
+// menus on request, so they don't have to be regenerated by a timer.
+func serveCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+	interval := fs.Duration("refresh", time.Hour, "How often to refetch the menus")
+	fetchOpts := addFetchFlags(fs)
+	opts := addRenderFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if err := fetchOpts.apply(); err != nil {
+		return err
+	}
+	if err := opts.apply(); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("invalid refresh interval %s", *interval)
+	}
+
+	server := &menuServer{}
+	server.refresh()
+	go func() {
+		for range time.Tick(*interval) {
+			server.refresh()
+		}
+	}()
+	log.Printf("Serving menus on http://%s/", *addr)
+	return http.ListenAndServe(*addr, server)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeStatus(t *testing.T) {
+	plans := samplePlans(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "narrow.tmpl"), []byte("{{.NoSuchField}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	templateDir = dir
+	defer func() { templateDir = "" }()
+
+	s := &menuServer{plans: []MenuPlan{plans["jku"], plans["khg"]}}
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/", http.StatusOK},
+		{"/json?day=2025-11-05", http.StatusOK},
+		{"/json?day=wednesday", http.StatusOK},
+		{"/nope", http.StatusNotFound},
+		// Malformed input.
+		{"/json?day=foo", http.StatusBadRequest},
+		{"/?layout=bogus", http.StatusBadRequest},
+		// Days without menus.
+		{"/json?day=saturday", http.StatusNotFound},
+		{"/json?day=2020-01-01", http.StatusNotFound},
+		// A custom template that fails to render.
+		{"/?layout=narrow", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var serviceUnitTemplate = template.Must(template.New("service").Parse(`[Unit]
+Description=Generate the JKU Mensa & KHG weekly menu
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart={{.ExecStart}}
+`))
+
+var timerUnitTemplate = template.Must(template.New("timer").Parse(`[Unit]
+Description=Scheduled run of {{.Name}}.service
+
+[Timer]
+OnCalendar={{.Schedule}}
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`))
+
+// installService implements the install-service command: it writes (or
+// prints) a oneshot service and a timer running this binary on a schedule.
+// The output, lock, config, format, source and portable flags are passed on
+// to the service.
+func installService(args []string) error {
+	fs := flag.NewFlagSet("install-service", flag.ExitOnError)
+	userUnits := fs.Bool("user", false, "Install user units into ~/.config/systemd/user instead of /etc/systemd/system")
+	printOnly := fs.Bool("print", false, "Print the units to stdout instead of writing them")
+	name := fs.String("name", "jku-menu", "Name of the service and timer units")
+	schedule := fs.String("schedule", "*-*-* 06:00:00", "systemd OnCalendar expression for the timer")
+	outputFile := fs.String("o", "index.html", "Output filename passed to the service")
+	lockFile := fs.String("lock", "", "Lock file passed to the service (default: no locking)")
+	formatList := fs.String("format", "", "Output formats passed to the service (default: the service's default)")
+	sourceOpts := addSourceFlags(fs)
+	fs.BoolVar(&portable, "portable", false, "Resolve relative paths against the executable's directory, also in the service")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	// Fail now rather than on the service's first run.
+	if err := sourceOpts.apply(); err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("error locating executable: %w", err)
+	}
+	// The service runs with a different working directory, so relative
+	// paths would end up somewhere unexpected.
+	absPath := func(path string) (string, error) {
+		path, err := resolvePath(path)
+		if err != nil {
+			return "", err
+		}
+		if path, err = filepath.Abs(path); err != nil {
+			return "", fmt.Errorf("error resolving path: %w", err)
+		}
+		return path, nil
+	}
+	output, err := absPath(*outputFile)
+	if err != nil {
+		return err
+	}
+	execArgs := []string{exe, "-o", output}
+	if *lockFile != "" {
+		lock, err := absPath(*lockFile)
+		if err != nil {
+			return err
+		}
+		execArgs = append(execArgs, "-lock", lock)
+	}
+	// The config file is only passed on if one was chosen; otherwise the
+	// service reads the default one like any run.
+	if config, explicit := findFlag(args, "config", false); explicit || os.Getenv(envName("config")) != "" {
+		if !explicit {
+			config = os.Getenv(envName("config"))
+		}
+		if config != "" && config != defaultConfig {
+			if config, err = absPath(config); err != nil {
+				return err
+			}
+		}
+		execArgs = append(execArgs, "-config", config)
+	}
+	if *formatList != "" {
+		if _, err := parseFormats(*formatList); err != nil {
+			return err
+		}
+		execArgs = append(execArgs, "-format", *formatList)
+	}
+	if sourceOpts.location != "" {
+		execArgs = append(execArgs, "-location", sourceOpts.location)
+	}
+	if sourceOpts.static != "" {
+		var static []string
+		for _, entry := range strings.Split(sourceOpts.static, ",") {
+			id, path, _ := strings.Cut(entry, "=")
+			if path, err = absPath(strings.TrimSpace(path)); err != nil {
+				return err
+			}
+			static = append(static, strings.TrimSpace(id)+"="+path)
+		}
+		execArgs = append(execArgs, "-static", strings.Join(static, ","))
+	}
+	if sourceOpts.sources != "" {
+		execArgs = append(execArgs, "-sources", sourceOpts.sources)
+	}
+	if portable {
+		execArgs = append(execArgs, "-portable")
+	}
+	for i, arg := range execArgs {
+		execArgs[i] = systemdQuote(arg)
+	}
+
+	data := struct {
+		Name      string
+		Schedule  string
+		ExecStart string
+	}{Name: *name, Schedule: *schedule, ExecStart: strings.Join(execArgs, " ")}
+
+	units := []struct {
+		file string
+		tmpl *template.Template
+	}{
+		{*name + ".service", serviceUnitTemplate},
+		{*name + ".timer", timerUnitTemplate},
+	}
+
+	unitDir := "/etc/systemd/system"
+	systemctl := "systemctl"
+	if *userUnits {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return fmt.Errorf("error locating user config directory: %w", err)
+		}
+		unitDir = filepath.Join(configDir, "systemd", "user")
+		systemctl = "systemctl --user"
+	}
+	if !*printOnly {
+		if err := os.MkdirAll(unitDir, 0755); err != nil {
+			return fmt.Errorf("error creating unit directory: %w", err)
+		}
+	}
+
+	for _, unit := range units {
+		var buf bytes.Buffer
+		if err := unit.tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("error rendering %s: %w", unit.file, err)
+		}
+		if *printOnly {
+			fmt.Printf("# %s\n%s\n", unit.file, buf.String())
+			continue
+		}
+		path := filepath.Join(unitDir, unit.file)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	if !*printOnly {
+		fmt.Printf("Enable with: %s daemon-reload && %s enable --now %s.timer\n", systemctl, systemctl, *name)
+	}
+	return nil
+}
+
+// systemdQuote quotes a single ExecStart argument. Percent signs and dollar
+// signs are specifiers and variable references in unit files and have to be
+// doubled.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(arg) + `"`
+}
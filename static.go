@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"krenn.dev/menu/pkg/menu"
+)
+
+// staticMenu is a static menu file, for places with a fixed menu instead
+// of weekly plans. It is YAML, or JSON, which YAML includes:
+//
+//	name: Kebab stand
+//	short: Kebab
+//	color: "#c0392b"
+//	days: [mon, tue, wed, thu, fri]
+//	categories:
+//	  - name: Kebab
+//	    dishes:
+//	      - {title: Döner, price: "€ 6,50"}
+//	      - {title: Dürüm, price: "€ 7,00"}
+//
+// Only name and categories are required; the dishes are on every day
+// unless days lists the open ones. color is a hex color like #c0392b.
+type staticMenu struct {
+	Name       string   `yaml:"name"`
+	Short      string   `yaml:"short"`
+	Color      string   `yaml:"color"`
+	Days       []string `yaml:"days"`
+	Categories []struct {
+		Name   string `yaml:"name"`
+		Dishes []struct {
+			Title string `yaml:"title"`
+			Price string `yaml:"price"`
+		} `yaml:"dishes"`
+	} `yaml:"categories"`
+}
+
+func readStaticMenu(path string) (staticMenu, error) {
+	var m staticMenu
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, fmt.Errorf("error reading static menu: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%w: error parsing static menu %s: %w", menu.ErrParse, path, err)
+	}
+	if strings.TrimSpace(m.Name) == "" {
+		return m, fmt.Errorf("%w: static menu %s has no name", menu.ErrParse, path)
+	}
+	// The color goes into the pages as it is, so it has to be a hex color
+	// like one given with -source-color.
+	if m.Color != "" {
+		if _, err := parseHexColor(m.Color); err != nil {
+			return m, fmt.Errorf("%w: static menu %s: %w", menu.ErrParse, path, err)
+		}
+	}
+	return m, nil
+}
+
+// staticSource serves a static menu file as the plan of any week. The file
+// is read on every fetch, so edits show up with the next one.
+type staticSource struct {
+	path string
+}
+
+func (s staticSource) Name() string {
+	return "static menu " + s.path
+}
+
+func (s staticSource) Fetch(ctx context.Context) (MenuPlan, error) {
+	return s.plan(0)
+}
+
+func (s staticSource) FetchNextWeek(ctx context.Context) (MenuPlan, error) {
+	return s.plan(1)
+}
+
+// plan returns the menu as the plan of the week weeks after the current one.
+func (s staticSource) plan(weeks int) (MenuPlan, error) {
+	m, err := readStaticMenu(s.path)
+	if err != nil {
+		return MenuPlan{}, err
+	}
+	days := []Weekday{Monday, Tuesday, Wednesday, Thursday, Friday, Saturday, Sunday}
+	if len(m.Days) > 0 {
+		if days, err = parseWeekdays(strings.Join(m.Days, ",")); err != nil {
+			return MenuPlan{}, fmt.Errorf("%w: static menu %s: %w", menu.ErrParse, s.path, err)
+		}
+	}
+
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		return MenuPlan{}, fmt.Errorf("error loading time zone: %w", err)
+	}
+	year, week := time.Now().In(vienna).AddDate(0, 0, 7*weeks).ISOWeek()
+	plan := MenuPlan{Week: strconv.Itoa(week), Year: year, Title: m.Name}
+	for _, c := range m.Categories {
+		category := MenuCategory{Name: c.Name, Menus: make(map[Weekday][]Dish)}
+		for _, d := range c.Dishes {
+			dish := Dish{TitleDe: strings.TrimSpace(d.Title), Price: strings.TrimSpace(d.Price)}
+			for _, day := range days {
+				category.Menus[day] = append(category.Menus[day], dish)
+			}
+		}
+		plan.Menus = append(plan.Menus, category)
+	}
+	return plan, menu.Check(plan)
+}
+
+// addStaticSources adds the static menu files of list, like
+// "kebab=kebab.yaml,sushi=sushi.json", to the known sources. Name, short
+// name and color come from the files; the branding flags override them.
+func addStaticSources(list string) error {
+	for _, entry := range strings.Split(list, ",") {
+		id, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" || strings.TrimSpace(path) == "" {
+			return fmt.Errorf("invalid static menu %q, expected id=file", entry)
+		}
+		if slices.ContainsFunc(knownSources, func(s menuSource) bool { return s.ID == id }) {
+			return fmt.Errorf("static menu id %q is already taken", id)
+		}
+		path, err := resolvePath(strings.TrimSpace(path))
+		if err != nil {
+			return err
+		}
+		m, err := readStaticMenu(path)
+		if err != nil {
+			return err
+		}
+		color := m.Color
+		if color == "" {
+			color = defaultSourceColor
+		}
+		knownSources = append(knownSources, menuSource{
+			ID:     id,
+			Name:   m.Name,
+			Short:  m.Short,
+			Color:  color,
+			Source: staticSource{path: path},
+		})
+	}
+	return nil
+}
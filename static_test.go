@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"krenn.dev/menu/pkg/menu"
+)
+
+func TestReadStaticMenuColor(t *testing.T) {
+	tests := []struct {
+		color string
+		err   error
+	}{
+		{"", nil},
+		{"#c0392b", nil},
+		{"red", menu.ErrParse},
+		{`red"><script>alert(1)</script><x y="`, menu.ErrParse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.color, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kebab.yaml")
+			data := "name: Kebab stand\ncolor: '" + tt.color + "'\ncategories:\n  - name: Kebab\n    dishes: [{title: Döner}]\n"
+			if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := readStaticMenu(path)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("readStaticMenu() error = %v, want %v", err, tt.err)
+			}
+			_, err = staticSource{path: path}.Fetch(context.Background())
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Fetch() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//go:embed status.tmpl
+var statusTemplate string
+
+// statusLabels describe the source states of a run report for visitors.
+var statusLabels = map[string]string{
+	"ok":      "Live",
+	"warning": "Live, but incomplete",
+	"cached":  "Unavailable, showing last known menu",
+	"error":   "Unavailable",
+}
+
+// writeStatusPage writes a public page summarizing the run, so visitors can
+// tell a broken fetch from a canteen that hasn't published its menu yet.
+// Error messages are left out since they may contain upstream responses.
+func writeStatusPage(path string, report *RunReport) error {
+	type sourceStatus struct {
+		SourceReport
+		Label string
+	}
+	year, week := time.Now().ISOWeek()
+	data := struct {
+		Updated     string
+		CurrentWeek string
+		Sources     []sourceStatus
+	}{
+		Updated:     time.Now().Format("Mon, 02 Jan 2006 15:04 MST"),
+		CurrentWeek: fmt.Sprintf("%d / %d", week, year),
+	}
+	for _, source := range report.Sources {
+		data.Sources = append(data.Sources, sourceStatus{SourceReport: source, Label: statusLabels[source.Status]})
+	}
+
+	tmpl, err := template.New("status").Parse(statusTemplate)
+	if err != nil {
+		return fmt.Errorf("error parsing status template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("error rendering status page: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating status page directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing status page: %w", err)
+	}
+	return nil
+}
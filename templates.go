@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+)
+
+// templateDir holds custom templates extending the built-in ones of the
+// same file name, set with -template-dir. Empty means built-in only.
+var templateDir string
+
+// Custom templates run with these limits, so a broken one fails the render
+// instead of hanging the server or filling the disk. Steps are loop
+// iterations and template calls.
+const (
+	customTemplateTimeout  = 5 * time.Second
+	customTemplateMaxSize  = 8 << 20
+	customTemplateMaxSteps = 100_000
+)
+
+// templateStepFunc is the function parseCustomTemplate calls at the start
+// of every loop iteration and template.
+const templateStepFunc = "templateStep"
+
+// templateLimits tracks one render's custom template against the limits.
+type templateLimits struct {
+	custom   bool
+	steps    atomic.Int64
+	canceled atomic.Bool
+}
+
+// funcs are added to the templates that custom ones extend. They replace
+// the builtins that reach beyond the data they are given (call runs any
+// function value found in it) and count the steps.
+func (l *templateLimits) funcs() map[string]any {
+	return map[string]any{
+		"call": func(...any) (any, error) {
+			return nil, errors.New("call is not allowed in custom templates")
+		},
+		templateStepFunc: l.step,
+	}
+}
+
+func (l *templateLimits) step() (string, error) {
+	if l.canceled.Load() {
+		return "", errors.New("template canceled")
+	}
+	if l.steps.Add(1) > customTemplateMaxSteps {
+		return "", fmt.Errorf("custom template exceeds %d loop iterations and template calls", customTemplateMaxSteps)
+	}
+	return "", nil
+}
+
+// parseCustomTemplate parses the custom template file name from
+// templateDir, if there is one, into tmpl, which already holds the
+// built-in template. The HTML layouts define the partials "day-card" (the
+// menus of one day), "source-header" (the name of a canteen) and
+// "dish-row" (one dish). A file of only {{define}} blocks replaces those
+// partials; any other text replaces the layout, which can still use the
+// built-in partials. If a custom template was parsed, limits.custom is set
+// and every template in tmpl counts its steps against limits.
+func parseCustomTemplate[T interface{ Parse(string) (T, error) }](tmpl T, name string, limits *templateLimits) error {
+	if templateDir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(templateDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading custom template: %w", err)
+	}
+	if _, err := tmpl.Parse(string(data)); err != nil {
+		return fmt.Errorf("error parsing custom template %s: %w", name, err)
+	}
+	limits.custom = true
+
+	var trees []*parse.Tree
+	switch t := any(tmpl).(type) {
+	case *texttemplate.Template:
+		for _, t := range t.Templates() {
+			trees = append(trees, t.Tree)
+		}
+	case *htmltemplate.Template:
+		for _, t := range t.Templates() {
+			trees = append(trees, t.Tree)
+		}
+	}
+	step, err := parse.Parse("step", "{{"+templateStepFunc+"}}", "", "", limits.funcs())
+	if err != nil {
+		return fmt.Errorf("error parsing template step: %w", err)
+	}
+	action := step["step"].Root.Nodes[0]
+	for _, tree := range trees {
+		if tree != nil && tree.Root != nil {
+			addSteps(tree.Root, action)
+			tree.Root.Nodes = append([]parse.Node{action.Copy()}, tree.Root.Nodes...)
+		}
+	}
+	return nil
+}
+
+// addSteps puts a copy of action at the start of every range body within
+// list.
+func addSteps(list *parse.ListNode, action parse.Node) {
+	for _, n := range list.Nodes {
+		var branch *parse.BranchNode
+		switch n := n.(type) {
+		case *parse.IfNode:
+			branch = &n.BranchNode
+		case *parse.WithNode:
+			branch = &n.BranchNode
+		case *parse.RangeNode:
+			branch = &n.BranchNode
+		default:
+			continue
+		}
+		for _, l := range []*parse.ListNode{branch.List, branch.ElseList} {
+			if l != nil {
+				addSteps(l, action)
+			}
+		}
+		if n.Type() == parse.NodeRange && branch.List != nil {
+			branch.List.Nodes = append([]parse.Node{action.Copy()}, branch.List.Nodes...)
+		}
+	}
+}
+
+// templateExecutor is implemented by both text and html templates.
+type templateExecutor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// executeTemplate executes tmpl with data. Custom templates are stopped
+// after customTemplateTimeout, customTemplateMaxSize bytes of output or
+// customTemplateMaxSteps steps.
+func executeTemplate(tmpl templateExecutor, data any, limits *templateLimits) ([]byte, error) {
+	if !limits.custom {
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, data)
+		return buf.Bytes(), err
+	}
+	w := &limitedWriter{max: customTemplateMaxSize}
+	done := make(chan error, 1)
+	go func() { done <- tmpl.Execute(w, data) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+		return w.buf.Bytes(), nil
+	case <-time.After(customTemplateTimeout):
+		// Templates can't be interrupted; the execution stops at its next
+		// step or write instead.
+		limits.canceled.Store(true)
+		w.cancel()
+		return nil, fmt.Errorf("custom template didn't finish within %s", customTemplateTimeout)
+	}
+}
+
+// limitedWriter buffers up to max bytes and fails writes beyond that or
+// after cancel.
+type limitedWriter struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	max      int
+	canceled bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.canceled {
+		return 0, errors.New("template canceled")
+	}
+	if w.buf.Len()+len(p) > w.max {
+		return 0, fmt.Errorf("custom template output exceeds %d bytes", w.max)
+	}
+	return w.buf.Write(p)
+}
+
+func (w *limitedWriter) cancel() {
+	w.mu.Lock()
+	w.canceled = true
+	w.mu.Unlock()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Column widths of the terminal tables, in characters.
+const (
+	textCategoryWidth = 24
+	textDishWidth     = 56
+)
+
+// renderText renders days as aligned Unicode tables for terminals, one table
+// per canteen and day. With -ascii the tables are drawn with +, - and |.
+func renderText(days []DayMenus, title string) []byte {
+	if asciiOutput {
+		// Transliterate before the layout, as "ä" becomes two characters.
+		days, title = asciiDays(days), toASCII(title)
+	}
+	var b strings.Builder
+	b.WriteString(title + "\n")
+	for _, day := range days {
+		for _, menu := range day.Canteens() {
+			fmt.Fprintf(&b, "\n%s — %s\n", day.Name, menu.Source.Name)
+			for _, annotation := range menu.Annotations {
+				b.WriteString(annotation.String() + "\n")
+			}
+			if menu.Closed != "" {
+				b.WriteString(menu.Closed + "\n")
+				continue
+			}
+			if len(menu.Categories) == 0 {
+				fmt.Fprintf(&b, "No menu data found for %s.\n", day.Name)
+				continue
+			}
+			var rows [][]string
+			for _, category := range menu.Categories {
+				for _, dish := range category.Dishes {
+					rows = append(rows, []string{category.Name, dish.Title, dish.Price})
+				}
+			}
+			writeTextTable(&b, []string{"Category", "Dish", "Price"}, rows, []int{textCategoryWidth, textDishWidth, 0})
+		}
+	}
+	if asciiOutput {
+		return []byte(toASCII(b.String()))
+	}
+	return []byte(b.String())
+}
+
+// writeTextTable writes a box-drawn table. Cells are wrapped to maxWidths;
+// a max width of 0 means the column is as wide as its widest cell. The last
+// column is right-aligned.
+func writeTextTable(b *strings.Builder, header []string, rows [][]string, maxWidths []int) {
+	widths := make([]int, len(header))
+	wrapped := make([][][]string, len(rows))
+	measure := func(cells [][]string) {
+		for i, lines := range cells {
+			for _, line := range lines {
+				widths[i] = max(widths[i], utf8.RuneCountInString(line))
+			}
+		}
+	}
+	headerCells := make([][]string, len(header))
+	for i, h := range header {
+		headerCells[i] = []string{h}
+	}
+	measure(headerCells)
+	for r, row := range rows {
+		wrapped[r] = make([][]string, len(row))
+		for i, cell := range row {
+			wrapped[r][i] = wrapRunes(cell, maxWidths[i])
+		}
+		measure(wrapped[r])
+	}
+
+	rule := func(left, mid, right string) {
+		b.WriteString(left)
+		for i, w := range widths {
+			if i > 0 {
+				b.WriteString(mid)
+			}
+			b.WriteString(strings.Repeat("─", w+2))
+		}
+		b.WriteString(right + "\n")
+	}
+	line := func(cells [][]string) {
+		height := 0
+		for _, lines := range cells {
+			height = max(height, len(lines))
+		}
+		for l := 0; l < height; l++ {
+			b.WriteString("│")
+			for i, lines := range cells {
+				text := ""
+				if l < len(lines) {
+					text = lines[l]
+				}
+				pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(text))
+				if i == len(cells)-1 {
+					b.WriteString(" " + pad + text + " │")
+				} else {
+					b.WriteString(" " + text + pad + " │")
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	rule("┌", "┬", "┐")
+	line(headerCells)
+	rule("├", "┼", "┤")
+	for r, row := range wrapped {
+		if r > 0 {
+			rule("├", "┼", "┤")
+		}
+		line(row)
+	}
+	rule("└", "┴", "┘")
+}
+
+// wrapRunes word-wraps s to lines of at most width characters; 0 disables
+// wrapping. Words longer than width are split.
+func wrapRunes(s string, width int) []string {
+	words := strings.Fields(s)
+	if width <= 0 {
+		return []string{strings.Join(words, " ")}
+	}
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for utf8.RuneCountInString(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+	"krenn.dev/menu/pkg/menu"
+)
+
+// DishView is a dish prepared for display.
+type DishView struct {
+	Title     string // plain text
+	TitleHTML string // markup as delivered by the source, e.g. with <br />
+	Price     string // formatted with formatPrice, may be empty
+}
+
+type CategoryView struct {
+	Name   string
+	Dishes []DishView
+}
+
+type MenuView struct {
+	Source     SourceView
+	Categories []CategoryView
+	Closed     string // shown instead of the menu on closed days, e.g. "Closed: Rector's day"
+	// Annotations are notices about the source's data, e.g. that it was
+	// served from the cache.
+	Annotations []Annotation
+}
+
+// SourceView is the branding of a source.
+type SourceView struct {
+	ID    string
+	Name  string
+	Short string
+	Color string
+	Logo  template.URL // checked by validateLogo
+}
+
+// DayMenus holds what the canteens offer on one date.
+type DayMenus struct {
+	ID    string // HTML anchor, e.g. "monday"
+	Name  string
+	Date  time.Time
+	Menus []MenuView // one per source, in the order of sources
+}
+
+// Canteens returns the menus of the day in display order.
+func (d DayMenus) Canteens() []MenuView {
+	return d.Menus
+}
+
+// showNextWeek shows next week's plans instead of the current ones, set
+// with -week next.
+var showNextWeek bool
+
+// shownWeeks returns the plans to show and those of the week after, given
+// the current and next week's plans.
+func shownWeeks(plans, next []MenuPlan) ([]MenuPlan, []MenuPlan) {
+	if showNextWeek {
+		return next, nil
+	}
+	return plans, next
+}
+
+// displayedWeekdays are the days shown by the renderers, set with -days.
+var displayedWeekdays = []Weekday{Monday, Tuesday, Wednesday, Thursday, Friday}
+
+// weekStart is the first day of a displayed week, Monday or Sunday. A week
+// starting on Sunday begins the day before the ISO week.
+var weekStart = Monday
+
+// displayedDates returns the dates of the displayed days of the week
+// containing monday, in display order.
+func displayedDates(monday time.Time) []time.Time {
+	shift := int(weekStart - Monday)
+	if shift > 0 {
+		shift -= 7
+	}
+	var dates []time.Time
+	for i := 0; i < 7; i++ {
+		date := monday.AddDate(0, 0, shift+i)
+		if slices.Contains(displayedWeekdays, weekdayOf(date)) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// parseWeekday parses an English day name or its three-letter abbreviation.
+func parseWeekday(s string) (Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := Monday; d <= Sunday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseWeekdays parses a list like "mon,tue,wed,thu".
+func parseWeekdays(list string) ([]Weekday, error) {
+	var days []Weekday
+	for _, name := range strings.Split(list, ",") {
+		d, ok := parseWeekday(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q", name)
+		}
+		if !slices.Contains(days, d) {
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+// buildDayMenus prepares the view models shared by all renderers. Dishes are
+// matched by date, so a source that already (or still) publishes a
+// different week than the one displayed doesn't show up under the wrong
+// days.
+func buildDayMenus(week WeekMenu) []DayMenus {
+	var days []DayMenus
+	for _, date := range displayedDates(week.Monday()) {
+		weekday := weekdayOf(date)
+		day := DayMenus{
+			ID:   strings.ToLower(weekday.String()),
+			Name: weekday.String(),
+			Date: date,
+		}
+		reason, closed := closedDates[DateOf(date)]
+		for _, s := range sources {
+			menu := buildMenuView(sourceView(s, week), week.Offerings(DateOf(date), s.ID))
+			menu.Annotations = week.SourceAnnotations(s.ID)
+			if closed {
+				menu.Closed = closureNote(reason)
+			}
+			day.Menus = append(day.Menus, menu)
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// sourceView returns the branding of s, named as published in week if s
+// asks for it.
+func sourceView(s menuSource, week WeekMenu) SourceView {
+	view := SourceView{ID: s.ID, Name: s.Name, Short: s.Short, Color: s.Color, Logo: template.URL(s.Logo)}
+	if title := week.Titles[s.ID]; s.PublishedName && title != "" {
+		view.Name = title
+	}
+	if view.Short == "" {
+		view.Short = view.Name
+	}
+	return view
+}
+
+func buildMenuView(source SourceView, offerings []Offering) MenuView {
+	var categories []CategoryView
+	for _, category := range groupByCategory(offerings) {
+		var dishViews []DishView
+		for _, dish := range category.Dishes {
+			dishViews = append(dishViews, DishView{
+				Title:     plainTitle(dish.TitleDe),
+				TitleHTML: formatTitleForHTML(dish.TitleDe),
+				Price:     formatPrice(dish.Price),
+			})
+		}
+		categories = append(categories, CategoryView{Name: category.Name, Dishes: dishViews})
+	}
+	return MenuView{
+		Source:     source,
+		Categories: categories,
+	}
+}
+
+// selectDay returns the entry of days for spec: "today", "tomorrow", a
+// weekday name or a date as YYYY-MM-DD. Today and tomorrow are taken in
+// Vienna time, weekday names in the week of the first of days. A date that
+// isn't displayed, like a Saturday, falls forward to the next displayed day
+// if that is part of days; otherwise the canteens are reported closed.
+func selectDay(days []DayMenus, spec string, now time.Time) (DayMenus, error) {
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		return DayMenus{}, fmt.Errorf("error loading time zone: %w", err)
+	}
+	var date Date
+	switch spec {
+	case "today":
+		date = DateOf(now.In(vienna))
+	case "tomorrow":
+		date = DateOf(now.In(vienna)).AddDays(1)
+	default:
+		if weekday, ok := parseWeekday(spec); ok {
+			if len(days) == 0 {
+				return DayMenus{}, &dayError{message: fmt.Sprintf("no menus for %s", weekday)}
+			}
+			start := DateOf(days[0].Date).AddDays(-dayOfWeek(weekdayOf(days[0].Date)))
+			date = start.AddDays(dayOfWeek(weekday))
+			break
+		}
+		t, err := time.Parse("2006-01-02", spec)
+		if err != nil {
+			return DayMenus{}, &dayError{message: fmt.Sprintf("invalid day %q, want today, tomorrow, a weekday or YYYY-MM-DD", spec), invalid: true}
+		}
+		date = DateOf(t)
+	}
+
+	next := date
+	for i := 0; i < 7 && !slices.Contains(displayedWeekdays, weekdayOf(next.In(time.UTC))); i++ {
+		next = next.AddDays(1)
+	}
+	for _, day := range days {
+		if DateOf(day.Date) == next {
+			return day, nil
+		}
+	}
+	if next != date {
+		return DayMenus{}, &dayError{message: "closed on " + date.In(time.UTC).Format("Monday, 2006-01-02")}
+	}
+	return DayMenus{}, &dayError{message: "no menus for " + date.In(time.UTC).Format("Monday, 2006-01-02")}
+}
+
+// dayError is an error of selectDay about the day asked for, as opposed to
+// a failure to render it.
+type dayError struct {
+	message string
+	invalid bool // the day couldn't be parsed; otherwise it has no menus
+}
+
+func (e *dayError) Error() string {
+	return e.message
+}
+
+// dayOfWeek returns the position of weekday in a displayed week, 0 for
+// weekStart.
+func dayOfWeek(weekday Weekday) int {
+	return (int(weekday) - int(weekStart) + 7) % 7
+}
+
+// displayWeek returns the Monday of the week to display: the week of the
+// first plan that has one, or the current week.
+func displayWeek(menus ...MenuPlan) time.Time {
+	for _, menu := range menus {
+		if start, ok := menu.WeekStart(); ok {
+			return start
+		}
+	}
+	now := time.Now()
+	if showNextWeek {
+		now = now.AddDate(0, 0, 7)
+	}
+	year, week := now.ISOWeek()
+	return menu.ISOWeekStart(year, week, time.Local)
+}
+
+var (
+	reLineBreak = regexp.MustCompile(`(?i)<br\s*/?>`)
+	reTag       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// plainTitle turns a dish title, which may contain markup, into plain text
+// for non-HTML outputs.
+func plainTitle(title string) string {
+	title = reLineBreak.ReplaceAllString(title, " ")
+	title = reTag.ReplaceAllString(title, "")
+	title = html.UnescapeString(title)
+	// Some sources use decomposed umlauts, which fonts without combining
+	// marks can't draw.
+	title = norm.NFC.String(title)
+	return strings.Join(strings.Fields(title), " ")
+}
+
+func formatTitleForHTML(title string) string {
+	r := strings.NewReplacer("\n", " ")
+	cleaned := r.Replace(title)
+	return strings.TrimSpace(cleaned)
+}
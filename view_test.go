@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectDay(t *testing.T) {
+	plans := samplePlans(t)
+	days := buildDayMenus(buildWeekMenu(plans["jku"], plans["khg"]))
+	now := time.Date(2025, time.November, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want string // date selected, or the error
+	}{
+		{"today", "2025-11-04"},
+		{"tomorrow", "2025-11-05"},
+		{"wed", "2025-11-05"},
+		{"Friday", "2025-11-07"},
+		{"2025-11-06", "2025-11-06"},
+		// Days that aren't displayed fall forward, or are closed at the
+		// end of the week, whether given by name or date.
+		{"saturday", "closed on Saturday, 2025-11-08"},
+		{"2025-11-08", "closed on Saturday, 2025-11-08"},
+		{"2025-11-02", "2025-11-03"},
+		{"2020-01-01", "no menus for Wednesday, 2020-01-01"},
+		{"someday", `invalid day "someday", want today, tomorrow, a weekday or YYYY-MM-DD`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			day, err := selectDay(days, tt.spec, now)
+			got := day.Date.Format("2006-01-02")
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Errorf("selectDay(%q) = %s, want %s", tt.spec, got, tt.want)
+			}
+		})
+	}
+}